@@ -0,0 +1,229 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shahanmmiah/Chirpy/internal/database"
+	"github.com/shahanmmiah/Chirpy/internal/pagination"
+)
+
+// RelationJson is one entry in the caller's block or mute list, or a member
+// of a list.
+type RelationJson struct {
+	UserID    uuid.UUID `json:"user_id"`
+	Username  string    `json:"username,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// MiddlewareBlockUser blocks the user in the path. Blocking also removes any
+// follow between the two users, in both directions, so neither keeps seeing
+// the other's chirps on their home timeline.
+func (a *ApiConfig) MiddlewareBlockUser() http.Handler {
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		userId, err := a.AuthenticatedUser(req)
+		if err != nil {
+			ErrorJsonResp(resp, err, UNAUTHORIZED)
+			return
+		}
+
+		userDb, ok := a.UserFromPath(resp, req)
+		if !ok {
+			return
+		}
+
+		if userDb.ID == userId {
+			ErrorJsonResp(resp, fmt.Errorf("cannot block yourself"), FAILEDCODE)
+			return
+		}
+
+		tx, err := a.Db.BeginTx(req.Context(), nil)
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+		defer tx.Rollback()
+
+		queries := a.DbQueries.WithTx(tx)
+
+		_, err = queries.BlockUser(req.Context(), database.BlockUserParams{
+			BlockerID: userId,
+			BlockedID: userDb.ID,
+			CreatedAt: time.Now()})
+
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		type follow struct{ follower, followee uuid.UUID }
+		for _, f := range []follow{{userId, userDb.ID}, {userDb.ID, userId}} {
+			n, err := queries.UnfollowUser(req.Context(), database.UnfollowUserParams{
+				FollowerID: f.follower,
+				FolloweeID: f.followee})
+
+			if err != nil {
+				ErrorJsonResp(resp, err, FAILEDCODE)
+				return
+			}
+			if n == 0 {
+				continue
+			}
+
+			err = a.AdjustFollowerCount(req.Context(), queries, f.followee, -1)
+			if err != nil {
+				ErrorJsonResp(resp, err, FAILEDCODE)
+				return
+			}
+
+			err = a.Fanout.Unfollow(req.Context(), queries, f.follower, f.followee)
+			if err != nil {
+				ErrorJsonResp(resp, err, FAILEDCODE)
+				return
+			}
+		}
+
+		err = tx.Commit()
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		a.Fanout.Wake()
+
+		resp.WriteHeader(NOCONTENTCODE)
+	})
+}
+
+func (a *ApiConfig) MiddlewareUnblockUser() http.Handler {
+	return a.relationChangeHandler(func(req *http.Request, userId, otherId uuid.UUID) error {
+		_, err := a.DbQueries.UnblockUser(req.Context(), database.UnblockUserParams{
+			BlockerID: userId,
+			BlockedID: otherId})
+		return err
+	})
+}
+
+func (a *ApiConfig) MiddlewareMuteUser() http.Handler {
+	return a.relationChangeHandler(func(req *http.Request, userId, otherId uuid.UUID) error {
+		_, err := a.DbQueries.MuteUser(req.Context(), database.MuteUserParams{
+			MuterID:   userId,
+			MutedID:   otherId,
+			CreatedAt: time.Now()})
+		return err
+	})
+}
+
+func (a *ApiConfig) MiddlewareUnmuteUser() http.Handler {
+	return a.relationChangeHandler(func(req *http.Request, userId, otherId uuid.UUID) error {
+		_, err := a.DbQueries.UnmuteUser(req.Context(), database.UnmuteUserParams{
+			MuterID: userId,
+			MutedID: otherId})
+		return err
+	})
+}
+
+// relationChangeHandler applies a single-row change between the caller and
+// the user in the path. Repeating a change is not an error.
+func (a *ApiConfig) relationChangeHandler(change func(*http.Request, uuid.UUID, uuid.UUID) error) http.Handler {
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		userId, err := a.AuthenticatedUser(req)
+		if err != nil {
+			ErrorJsonResp(resp, err, UNAUTHORIZED)
+			return
+		}
+
+		userDb, ok := a.UserFromPath(resp, req)
+		if !ok {
+			return
+		}
+
+		if userDb.ID == userId {
+			ErrorJsonResp(resp, fmt.Errorf("cannot do that to yourself"), FAILEDCODE)
+			return
+		}
+
+		err = change(req, userId, userDb.ID)
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		resp.WriteHeader(NOCONTENTCODE)
+	})
+}
+
+func (a *ApiConfig) MiddlewareGetBlocks() http.Handler {
+	return a.relationListHandler(func(req *http.Request, userId uuid.UUID, page pagination.Page) ([]RelationJson, error) {
+		rows, err := a.DbQueries.ListBlocks(req.Context(), database.ListBlocksParams{
+			UserID:          userId,
+			CursorCreatedAt: page.CursorCreatedAt(),
+			CursorID:        page.CursorID(),
+			RowLimit:        page.FetchLimit()})
+
+		relations := []RelationJson{}
+		for _, r := range rows {
+			relations = append(relations, RelationJson{UserID: r.User.ID, Username: r.User.Username.String, CreatedAt: r.BlockedAt})
+		}
+		return relations, err
+	})
+}
+
+func (a *ApiConfig) MiddlewareGetMutes() http.Handler {
+	return a.relationListHandler(func(req *http.Request, userId uuid.UUID, page pagination.Page) ([]RelationJson, error) {
+		rows, err := a.DbQueries.ListMutes(req.Context(), database.ListMutesParams{
+			UserID:          userId,
+			CursorCreatedAt: page.CursorCreatedAt(),
+			CursorID:        page.CursorID(),
+			RowLimit:        page.FetchLimit()})
+
+		relations := []RelationJson{}
+		for _, r := range rows {
+			relations = append(relations, RelationJson{UserID: r.User.ID, Username: r.User.Username.String, CreatedAt: r.MutedAt})
+		}
+		return relations, err
+	})
+}
+
+// relationListHandler pages through the caller's own blocks or mutes, newest
+// first. These lists are private, so there is no {userID} variant.
+func (a *ApiConfig) relationListHandler(list func(*http.Request, uuid.UUID, pagination.Page) ([]RelationJson, error)) http.Handler {
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		userId, err := a.AuthenticatedUser(req)
+		if err != nil {
+			ErrorJsonResp(resp, err, UNAUTHORIZED)
+			return
+		}
+
+		page, err := pagination.FromQuery(req.URL.Query())
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		relations, err := list(req, userId, page)
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		if len(relations) > int(page.Limit) {
+			relations = relations[:page.Limit]
+			last := relations[len(relations)-1]
+			resp.Header().Set("Link", pagination.NextLink(req.URL, pagination.Cursor{CreatedAt: last.CreatedAt, ID: last.UserID}.Encode()))
+		}
+
+		jsonData, err := json.Marshal(relations)
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		resp.Header().Set("Content-Type", "application/json")
+		resp.WriteHeader(OKCODE)
+		resp.Write(jsonData)
+	})
+}
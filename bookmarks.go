@@ -0,0 +1,126 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shahanmmiah/Chirpy/internal/database"
+	"github.com/shahanmmiah/Chirpy/internal/pagination"
+)
+
+// MiddlewareBookmarkChirp saves a chirp to the caller's bookmarks. Bookmarks
+// are private: nothing about them is shown to the author or anyone else.
+func (a *ApiConfig) MiddlewareBookmarkChirp() http.Handler {
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		userId, err := a.AuthenticatedUser(req)
+		if err != nil {
+			ErrorJsonResp(resp, err, UNAUTHORIZED)
+			return
+		}
+
+		chirpId, err := uuid.Parse(req.PathValue("chirpID"))
+		if err != nil {
+			ErrorJsonResp(resp, fmt.Errorf("invalid chirp id: %v", err), FAILEDCODE)
+			return
+		}
+
+		_, err = a.DbQueries.GetVisibleChirp(req.Context(), database.GetVisibleChirpParams{
+			ID:       chirpId,
+			ViewerID: uuid.NullUUID{UUID: userId, Valid: true}})
+
+		if errors.Is(err, sql.ErrNoRows) {
+			ErrorJsonResp(resp, fmt.Errorf("chirp %v not found", chirpId), NOTFOUNDCODE)
+			return
+		}
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		// bookmarking twice keeps the first bookmark
+		_, err = a.DbQueries.AddBookmark(req.Context(), database.AddBookmarkParams{
+			UserID:    userId,
+			ChirpID:   chirpId,
+			CreatedAt: time.Now()})
+
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		resp.WriteHeader(NOCONTENTCODE)
+	})
+}
+
+// MiddlewareUnbookmarkChirp removes a chirp from the caller's bookmarks. It
+// does not check the chirp is still visible, so bookmarks of chirps that have
+// since been hidden can still be cleared.
+func (a *ApiConfig) MiddlewareUnbookmarkChirp() http.Handler {
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		userId, err := a.AuthenticatedUser(req)
+		if err != nil {
+			ErrorJsonResp(resp, err, UNAUTHORIZED)
+			return
+		}
+
+		chirpId, err := uuid.Parse(req.PathValue("chirpID"))
+		if err != nil {
+			ErrorJsonResp(resp, fmt.Errorf("invalid chirp id: %v", err), FAILEDCODE)
+			return
+		}
+
+		_, err = a.DbQueries.RemoveBookmark(req.Context(), database.RemoveBookmarkParams{
+			UserID:  userId,
+			ChirpID: chirpId})
+
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		resp.WriteHeader(NOCONTENTCODE)
+	})
+}
+
+// MiddlewareGetBookmarks pages through the chirps the caller has bookmarked,
+// most recently bookmarked first.
+func (a *ApiConfig) MiddlewareGetBookmarks() http.Handler {
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		userId, err := a.AuthenticatedUser(req)
+		if err != nil {
+			ErrorJsonResp(resp, err, UNAUTHORIZED)
+			return
+		}
+
+		page, err := pagination.FromQuery(req.URL.Query())
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		rows, err := a.DbQueries.ListBookmarkChirps(req.Context(), database.ListBookmarkChirpsParams{
+			UserID:          userId,
+			CursorCreatedAt: page.CursorCreatedAt(),
+			CursorID:        page.CursorID(),
+			RowLimit:        page.FetchLimit()})
+
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		chirpsDb := []database.Chirp{}
+		for _, r := range rows {
+			chirpsDb = append(chirpsDb, r.Chirp)
+		}
+
+		// the cursor is the bookmark's time, not the chirp's
+		a.WriteChirpPageAt(resp, req, page, chirpsDb, func(i int) pagination.Cursor {
+			return pagination.Cursor{CreatedAt: rows[i].BookmarkedAt, ID: rows[i].Chirp.ID}
+		})
+	})
+}
@@ -1,17 +1,176 @@
 package main
 
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/shahanmmiah/Chirpy/internal/fanout"
+	"github.com/shahanmmiah/Chirpy/internal/media"
+	"github.com/shahanmmiah/Chirpy/internal/publisher"
+)
+
 const FRONTEND_NS = "/app"
 const BACKEND_NS = "/api"
 const ADMIN_NS = "/admin"
 
 const GET_METHOD = "GET"
 const POST_METHOD = "POST"
+const PUT_METHOD = "PUT"
+const DELETE_METHOD = "DELETE"
 
 const CENSORSTR = "****"
 
+const CHIRPLEN = 140
+const REDCHIRPLEN = 280
+
+const ACCESSTOKENEXPIRE = time.Hour
+const REFRESHTOKENEXPIRE = 60 * 24 * time.Hour
+
+// PREVIEWQUEUE is how many link previews can wait to be fetched before new
+// ones are dropped, and PREVIEWWORKERS how many are fetched at once.
+const PREVIEWQUEUE = 256
+const PREVIEWWORKERS = 4
+
+// Polls have POLLMINOPTIONS-POLLMAXOPTIONS options of up to POLLOPTIONLEN
+// characters each, and stay open for POLLMINDURATION-POLLMAXDURATION.
+const POLLMINOPTIONS = 2
+const POLLMAXOPTIONS = 4
+const POLLOPTIONLEN = 50
+const POLLMINDURATION = 5 * time.Minute
+const POLLMAXDURATION = 7 * 24 * time.Hour
+
+// EDITWINDOW is how long after posting a chirp its author can still edit it.
+const EDITWINDOW = 15 * time.Minute
+
+// RESTOREWINDOW is how long deleted chirps and accounts can be restored before
+// they are purged.
+const RESTOREWINDOW = 30 * 24 * time.Hour
+
+// MAXCHIRPMEDIA is how many uploads can be attached to one chirp.
+const MAXCHIRPMEDIA = 4
+
 const FAILEDCODE = 400
 const UNAUTHORIZED = 401
 const FORBIDDENCODE = 403
+const NOTFOUNDCODE = 404
+const CONFLICTCODE = 409
+const TOOLARGECODE = 413
+const UNSUPPORTEDCODE = 415
+const UNPROCESSABLECODE = 422
 
 const OKCODE = 200
 const NEWCODE = 201
+const ACCEPTEDCODE = 202
+const NOCONTENTCODE = 204
+
+// ChirpLimits holds the per-tier chirp length limits, measured in
+// user-perceived characters.
+type ChirpLimits struct {
+	Default   int
+	Red       int
+	URLWeight int
+}
+
+func (l ChirpLimits) For(isChirpyRed bool) int {
+	if isChirpyRed {
+		return l.Red
+	}
+	return l.Default
+}
+
+func envInt(name string, value *int) error {
+	raw := os.Getenv(name)
+	if raw == "" {
+		return nil
+	}
+
+	parsed, err := strconv.Atoi(raw)
+	if err != nil || parsed < 0 {
+		return fmt.Errorf("%v must be a non-negative number", name)
+	}
+
+	*value = parsed
+	return nil
+}
+
+func LoadChirpLimits() (ChirpLimits, error) {
+	limits := ChirpLimits{Default: CHIRPLEN, Red: REDCHIRPLEN}
+
+	for name, value := range map[string]*int{
+		"CHIRP_LIMIT":      &limits.Default,
+		"CHIRP_LIMIT_RED":  &limits.Red,
+		"CHIRP_URL_WEIGHT": &limits.URLWeight,
+	} {
+		if err := envInt(name, value); err != nil {
+			return ChirpLimits{}, err
+		}
+	}
+
+	return limits, nil
+}
+
+// LoadFanoutLimit reads the follower count above which chirps are merged into
+// home timelines at read time rather than copied to every follower.
+func LoadFanoutLimit() (int, error) {
+	limit := fanout.DefaultLimit
+	if err := envInt("CHIRP_FANOUT_LIMIT", &limit); err != nil {
+		return 0, err
+	}
+	return limit, nil
+}
+
+// LoadPublishInterval reads how often scheduled chirps are checked for, in
+// seconds.
+func LoadPublishInterval() (time.Duration, error) {
+	seconds := int(publisher.DefaultInterval.Seconds())
+	if err := envInt("CHIRP_PUBLISH_INTERVAL", &seconds); err != nil {
+		return 0, err
+	}
+	if seconds <= 0 {
+		return 0, fmt.Errorf("CHIRP_PUBLISH_INTERVAL must be positive")
+	}
+	return time.Duration(seconds) * time.Second, nil
+}
+
+// LoadEditWindow reads how long chirps stay editable, in seconds. Zero turns
+// editing off.
+func LoadEditWindow() (time.Duration, error) {
+	seconds := int(EDITWINDOW.Seconds())
+	if err := envInt("CHIRP_EDIT_WINDOW", &seconds); err != nil {
+		return 0, err
+	}
+	return time.Duration(seconds) * time.Second, nil
+}
+
+// LoadRestoreWindow reads how long deleted chirps and accounts are kept, in
+// days, before the purge job removes them.
+func LoadRestoreWindow() (time.Duration, error) {
+	days := int(RESTOREWINDOW / (24 * time.Hour))
+	if err := envInt("CHIRP_RESTORE_DAYS", &days); err != nil {
+		return 0, err
+	}
+	if days <= 0 {
+		return 0, fmt.Errorf("CHIRP_RESTORE_DAYS must be positive")
+	}
+	return time.Duration(days) * 24 * time.Hour, nil
+}
+
+// LoadMediaStore opens the directory uploads are kept in, served under
+// /app/media.
+func LoadMediaStore() (*media.LocalStore, error) {
+	dir := os.Getenv("CHIRP_MEDIA_DIR")
+	if dir == "" {
+		dir = "media"
+	}
+	return media.NewLocalStore(dir, FRONTEND_NS+"/media")
+}
+
+func LoadMediaMaxBytes() (int, error) {
+	maxBytes := media.MaxBytes
+	if err := envInt("CHIRP_MEDIA_MAX_BYTES", &maxBytes); err != nil {
+		return 0, err
+	}
+	return maxBytes, nil
+}
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shahanmmiah/Chirpy/internal/auth"
+	"github.com/shahanmmiah/Chirpy/internal/database"
+)
+
+// ChirpTombstone stands in for a deleted chirp that still has a place in a
+// thread. Only what is needed to hang its replies from is kept.
+func ChirpTombstone(chirpDb database.Chirp, replyCount int32) ChirpJson {
+	return ChirpJson{
+		ID:         chirpDb.ID,
+		CreatedAt:  chirpDb.CreatedAt,
+		UpdatedAt:  chirpDb.UpdatedAt,
+		InReplyTo:  NullUUIDToPtr(chirpDb.InReplyTo),
+		ReplyCount: replyCount,
+		ChirpKind:  chirpDb.ChirpKind,
+		Deleted:    true,
+		Status:     chirpDb.Status,
+		Tags:       []string{},
+		Mentions:   []MentionJson{},
+		Media:      []MediaJson{}}
+}
+
+// MiddlewareRestoreChirp undoes the deletion of one of the caller's chirps,
+// along with the rechirps that were deleted with it, as long as it is still
+// within window.
+func (a *ApiConfig) MiddlewareRestoreChirp(window time.Duration) http.Handler {
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		userId, err := a.AuthenticatedUser(req)
+		if err != nil {
+			ErrorJsonResp(resp, err, UNAUTHORIZED)
+			return
+		}
+
+		id, err := uuid.Parse(req.PathValue("chirpID"))
+		if err != nil {
+			ErrorJsonResp(resp, fmt.Errorf("invalid chirp id: %v", err), FAILEDCODE)
+			return
+		}
+
+		chirpDb, err := a.DbQueries.GetChirps(req.Context(), id)
+		if errors.Is(err, sql.ErrNoRows) || (err == nil && chirpDb.UserID != userId) {
+			ErrorJsonResp(resp, fmt.Errorf("chirp %v not found", id), NOTFOUNDCODE)
+			return
+		}
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		if !chirpDb.DeletedAt.Valid {
+			ErrorJsonResp(resp, fmt.Errorf("chirp %v is not deleted", id), CONFLICTCODE)
+			return
+		}
+
+		tx, err := a.Db.BeginTx(req.Context(), nil)
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+		defer tx.Rollback()
+
+		queries := a.DbQueries.WithTx(tx)
+
+		restoredDb, err := queries.RestoreChirp(req.Context(), database.RestoreChirpParams{
+			ID:           id,
+			UserID:       userId,
+			DeletedSince: sql.NullTime{Time: time.Now().Add(-window), Valid: true}})
+
+		if errors.Is(err, sql.ErrNoRows) {
+			ErrorJsonResp(resp, fmt.Errorf("chirps can only be restored for %d days after deleting", int(window/(24*time.Hour))), FORBIDDENCODE)
+			return
+		}
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		err = queries.RestoreRechirpsOf(req.Context(), database.RestoreRechirpsOfParams{
+			RefChirpID: uuid.NullUUID{UUID: id, Valid: true},
+			DeletedAt:  chirpDb.DeletedAt})
+
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		err = tx.Commit()
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		a.ChirpResp(resp, req, restoredDb, OKCODE)
+	})
+}
+
+// MiddlewareDeleteUser soft-deletes the caller's account together with their
+// chirps and signs them out everywhere. Access tokens that are already out
+// are refused by AuthenticatedUser from then on.
+func (a *ApiConfig) MiddlewareDeleteUser() http.Handler {
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		userId, err := a.AuthenticatedUser(req)
+		if err != nil {
+			ErrorJsonResp(resp, err, UNAUTHORIZED)
+			return
+		}
+
+		tx, err := a.Db.BeginTx(req.Context(), nil)
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+		defer tx.Rollback()
+
+		queries := a.DbQueries.WithTx(tx)
+		deletedAt := sql.NullTime{Time: time.Now(), Valid: true}
+
+		deleted, err := queries.SoftDeleteUser(req.Context(), database.SoftDeleteUserParams{
+			DeletedAt: deletedAt,
+			ID:        userId})
+
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+		if deleted == 0 {
+			ErrorJsonResp(resp, fmt.Errorf("unknown user %v", userId), UNAUTHORIZED)
+			return
+		}
+
+		// stamped with the account's time, so a restore can tell them apart
+		// from chirps the user had deleted themselves
+		err = queries.SoftDeleteUserChirps(req.Context(), database.SoftDeleteUserChirpsParams{
+			DeletedAt: deletedAt,
+			UserID:    userId})
+
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		err = queries.RevokeUserRefreshTokens(req.Context(), userId)
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		err = tx.Commit()
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		resp.WriteHeader(NOCONTENTCODE)
+	})
+}
+
+// MiddlewareRestoreUser brings back a deleted account and the chirps deleted
+// with it. A deleted user cannot log in, so it takes the same email and
+// password as a login; afterwards they log in as usual.
+func (a *ApiConfig) MiddlewareRestoreUser(window time.Duration) http.Handler {
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		userJson := &UserJson{}
+
+		reqData, err := io.ReadAll(req.Body)
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		err = json.Unmarshal(reqData, userJson)
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		userDb, err := a.DbQueries.GetUserFromEmail(req.Context(), userJson.Email)
+		if err != nil {
+			ErrorJsonResp(resp, fmt.Errorf("incorrect email or password"), UNAUTHORIZED)
+			return
+		}
+
+		err = auth.CheckPasswordHash(userJson.Password, userDb.HashedPassword)
+		if err != nil {
+			ErrorJsonResp(resp, fmt.Errorf("incorrect email or password"), UNAUTHORIZED)
+			return
+		}
+
+		if !userDb.DeletedAt.Valid {
+			ErrorJsonResp(resp, fmt.Errorf("account is not deleted"), CONFLICTCODE)
+			return
+		}
+
+		tx, err := a.Db.BeginTx(req.Context(), nil)
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+		defer tx.Rollback()
+
+		queries := a.DbQueries.WithTx(tx)
+
+		restoredDb, err := queries.RestoreUser(req.Context(), database.RestoreUserParams{
+			ID:           userDb.ID,
+			DeletedSince: sql.NullTime{Time: time.Now().Add(-window), Valid: true}})
+
+		if errors.Is(err, sql.ErrNoRows) {
+			ErrorJsonResp(resp, fmt.Errorf("accounts can only be restored for %d days after deleting", int(window/(24*time.Hour))), FORBIDDENCODE)
+			return
+		}
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		err = queries.RestoreUserChirps(req.Context(), database.RestoreUserChirpsParams{
+			DeletedAt: userDb.DeletedAt,
+			UserID:    userDb.ID})
+
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		err = tx.Commit()
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		jsonData, err := json.Marshal(UserDbToJson(restoredDb))
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		resp.Header().Set("Content-Type", "application/json")
+		resp.WriteHeader(OKCODE)
+		resp.Write(jsonData)
+	})
+}
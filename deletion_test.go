@@ -0,0 +1,39 @@
+package main
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shahanmmiah/Chirpy/internal/database"
+)
+
+func TestChirpTombstoneHidesContent(t *testing.T) {
+	parent := uuid.New()
+	chirpDb := database.Chirp{
+		ID:        uuid.New(),
+		CreatedAt: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC),
+		Body:      "something I regret",
+		UserID:    uuid.New(),
+		InReplyTo: uuid.NullUUID{UUID: parent, Valid: true},
+		LikeCount: 7,
+		ChirpKind: CHIRP_ORIGINAL,
+		Status:    CHIRP_PUBLISHED,
+		DeletedAt: sql.NullTime{Time: time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC), Valid: true}}
+
+	tombstone := ChirpTombstone(chirpDb, 3)
+
+	if !tombstone.Deleted {
+		t.Errorf("expected tombstone to be marked deleted")
+	}
+	if tombstone.Body != "" || tombstone.UserID != uuid.Nil || tombstone.LikeCount != 0 {
+		t.Errorf("tombstone leaks content: %+v", tombstone)
+	}
+	if tombstone.ID != chirpDb.ID || tombstone.InReplyTo == nil || *tombstone.InReplyTo != parent {
+		t.Errorf("tombstone lost its place in the thread: %+v", tombstone)
+	}
+	if tombstone.ReplyCount != 3 {
+		t.Errorf("expected reply count 3, got %d", tombstone.ReplyCount)
+	}
+}
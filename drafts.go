@@ -0,0 +1,318 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shahanmmiah/Chirpy/internal/database"
+	"github.com/shahanmmiah/Chirpy/internal/media"
+	"github.com/shahanmmiah/Chirpy/internal/moderation"
+	"github.com/shahanmmiah/Chirpy/internal/pagination"
+)
+
+const CHIRP_DRAFT = "draft"
+const CHIRP_SCHEDULED = "scheduled"
+const CHIRP_PUBLISHED = "published"
+
+// ParseChirpStatus validates the status a chirp is saved with. A scheduled
+// chirp needs an RFC 3339 publish_at after now; the other statuses take none.
+func ParseChirpStatus(rawStatus, rawPublishAt string, now time.Time) (string, sql.NullTime, error) {
+	switch rawStatus {
+	case CHIRP_DRAFT, CHIRP_PUBLISHED:
+		if rawPublishAt != "" {
+			return "", sql.NullTime{}, fmt.Errorf("publish_at is only allowed on %s chirps", CHIRP_SCHEDULED)
+		}
+		return rawStatus, sql.NullTime{}, nil
+
+	case CHIRP_SCHEDULED:
+		publishAt, err := time.Parse(time.RFC3339, rawPublishAt)
+		if err != nil {
+			return "", sql.NullTime{}, fmt.Errorf("invalid publish_at: %v", err)
+		}
+		if !publishAt.After(now) {
+			return "", sql.NullTime{}, fmt.Errorf("publish_at must be in the future")
+		}
+		return rawStatus, sql.NullTime{Time: publishAt, Valid: true}, nil
+	}
+
+	return "", sql.NullTime{}, fmt.Errorf("status must be %s, %s or %s", CHIRP_DRAFT, CHIRP_SCHEDULED, CHIRP_PUBLISHED)
+}
+
+func NullTimeToPtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+// PublishChirp does the work that has to commit along with a chirp becoming
+// public, however it got there: storing its tags and mentions, timing its
+// poll from now and queueing its fan-out. queries must belong to the
+// transaction that publishes it. Tags and mentions wait until now so drafts
+// never show up in tag pages or notify anyone, and polls so they do not close
+// before anyone can see them.
+func (a *ApiConfig) PublishChirp(ctx context.Context, queries *database.Queries, chirpDb database.Chirp) error {
+	err := StoreChirpEntities(ctx, queries, chirpDb)
+	if err != nil {
+		return err
+	}
+
+	err = queries.StartPoll(ctx, database.StartPollParams{
+		StartedAt: time.Now(),
+		ChirpID:   uuid.NullUUID{UUID: chirpDb.ID, Valid: true}})
+
+	if err != nil {
+		return err
+	}
+
+	return a.Fanout.Chirp(ctx, queries, chirpDb.ID)
+}
+
+// ChirpPublished starts the background work for a chirp once the transaction
+// that published it has committed.
+func (a *ApiConfig) ChirpPublished(chirpDb database.Chirp) {
+	a.Fanout.Wake()
+	a.Previews.Chirp(chirpDb.ID, chirpDb.Body)
+}
+
+// PrepareScheduledChirp runs in the publisher's transaction. Everything
+// checked when the chirp was scheduled is checked again, since the
+// moderation rules may have changed, and the chirps it replies to or quotes
+// may have been deleted or their authors may have blocked its author. A chirp
+// that no longer passes goes back to the author's drafts instead of out.
+func (a *ApiConfig) PrepareScheduledChirp(ctx context.Context, queries *database.Queries, chirpDb database.Chirp) (bool, error) {
+	problem := ""
+
+	moderated := a.Moderator.Check(chirpDb.Body)
+	if moderated.Action == moderation.PolicyReject || moderated.Action == moderation.PolicyHold {
+		problem = "it contains words that are not allowed: " + strings.Join(moderated.MatchedWords(), ", ")
+	}
+
+	for _, ref := range []uuid.NullUUID{chirpDb.InReplyTo, chirpDb.RefChirpID} {
+		if problem != "" || !ref.Valid {
+			continue
+		}
+
+		// a block in either direction hides the chirp, as it would have
+		// when the chirp was scheduled
+		_, err := queries.GetVisibleChirp(ctx, database.GetVisibleChirpParams{
+			ID:       ref.UUID,
+			ViewerID: uuid.NullUUID{UUID: chirpDb.UserID, Valid: true}})
+
+		if errors.Is(err, sql.ErrNoRows) {
+			problem = fmt.Sprintf("chirp %v it refers to is gone", ref.UUID)
+		} else if err != nil {
+			return false, err
+		}
+	}
+
+	if problem != "" {
+		log.Printf("publisher: chirp %v returned to drafts: %s", chirpDb.ID, problem)
+		return false, queries.ReturnToDraft(ctx, database.ReturnToDraftParams{
+			UpdatedAt: time.Now(),
+			ID:        chirpDb.ID})
+	}
+
+	if moderated.Text != chirpDb.Body {
+		var err error
+		chirpDb, err = queries.CensorChirp(ctx, database.CensorChirpParams{
+			Body: moderated.Text,
+			ID:   chirpDb.ID})
+
+		if err != nil {
+			return false, err
+		}
+	}
+
+	return true, a.PublishChirp(ctx, queries, chirpDb)
+}
+
+func (a *ApiConfig) MiddlewareGetDrafts() http.Handler {
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		userId, err := a.AuthenticatedUser(req)
+		if err != nil {
+			ErrorJsonResp(resp, err, UNAUTHORIZED)
+			return
+		}
+
+		page, err := pagination.FromQuery(req.URL.Query())
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		chirpsDb, err := a.DbQueries.ListDrafts(req.Context(), database.ListDraftsParams{
+			UserID:          userId,
+			CursorCreatedAt: page.CursorCreatedAt(),
+			CursorID:        page.CursorID(),
+			RowLimit:        page.FetchLimit()})
+
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		a.WriteChirpPage(resp, req, page, chirpsDb)
+	})
+}
+
+// MiddlewareUpdateDraft replaces the body, status and publish_at of one of the
+// caller's unpublished chirps. Omitting status keeps it a draft. Setting it to
+// published posts the chirp straight away, dated now rather than when the
+// draft was started.
+func (a *ApiConfig) MiddlewareUpdateDraft(limits ChirpLimits) http.Handler {
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		userId, err := a.AuthenticatedUser(req)
+		if err != nil {
+			ErrorJsonResp(resp, err, UNAUTHORIZED)
+			return
+		}
+
+		id, err := uuid.Parse(req.PathValue("chirpID"))
+		if err != nil {
+			ErrorJsonResp(resp, fmt.Errorf("invalid chirp id: %v", err), FAILEDCODE)
+			return
+		}
+
+		resData := struct {
+			Body      string `json:"body"`
+			Status    string `json:"status"`
+			PublishAt string `json:"publish_at"`
+		}{}
+
+		reqData, err := io.ReadAll(req.Body)
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		err = json.Unmarshal(reqData, &resData)
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		if resData.Status == "" {
+			resData.Status = CHIRP_DRAFT
+		}
+
+		now := time.Now()
+		status, publishAt, err := ParseChirpStatus(resData.Status, resData.PublishAt, now)
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		_, err = a.DbQueries.GetDraft(req.Context(), database.GetDraftParams{ID: id, UserID: userId})
+		if errors.Is(err, sql.ErrNoRows) {
+			ErrorJsonResp(resp, fmt.Errorf("draft %v not found", id), NOTFOUNDCODE)
+			return
+		}
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		if !a.CheckChirpLength(resp, req, userId, resData.Body, limits) {
+			return
+		}
+
+		moderated, ok := a.ModerateWithoutHold(resp, resData.Body)
+		if !ok {
+			return
+		}
+
+		tx, err := a.Db.BeginTx(req.Context(), nil)
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+		defer tx.Rollback()
+
+		queries := a.DbQueries.WithTx(tx)
+
+		// the status check in the update loses cleanly to the publisher if it
+		// claims the chirp first
+		chirpDb, err := queries.UpdateDraft(req.Context(), database.UpdateDraftParams{
+			Body:      moderated.Text,
+			Status:    status,
+			PublishAt: publishAt,
+			UpdatedAt: now,
+			ID:        id,
+			UserID:    userId})
+
+		if errors.Is(err, sql.ErrNoRows) {
+			ErrorJsonResp(resp, fmt.Errorf("draft %v has already been published", id), CONFLICTCODE)
+			return
+		}
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		if status == CHIRP_PUBLISHED {
+			err = a.PublishChirp(req.Context(), queries, chirpDb)
+			if err != nil {
+				ErrorJsonResp(resp, err, FAILEDCODE)
+				return
+			}
+		}
+
+		err = tx.Commit()
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		if status == CHIRP_PUBLISHED {
+			a.ChirpPublished(chirpDb)
+		}
+		a.ChirpResp(resp, req, chirpDb, OKCODE)
+	})
+}
+
+// MiddlewareDeleteDraft cancels one of the caller's unpublished chirps along
+// with its media.
+func (a *ApiConfig) MiddlewareDeleteDraft() http.Handler {
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		userId, err := a.AuthenticatedUser(req)
+		if err != nil {
+			ErrorJsonResp(resp, err, UNAUTHORIZED)
+			return
+		}
+
+		id, err := uuid.Parse(req.PathValue("chirpID"))
+		if err != nil {
+			ErrorJsonResp(resp, fmt.Errorf("invalid chirp id: %v", err), FAILEDCODE)
+			return
+		}
+
+		mediaDb, err := a.DbQueries.ListMediaForChirps(req.Context(), []uuid.UUID{id})
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		deleted, err := a.DbQueries.DeleteDraft(req.Context(), database.DeleteDraftParams{ID: id, UserID: userId})
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+		if deleted == 0 {
+			ErrorJsonResp(resp, fmt.Errorf("draft %v not found", id), NOTFOUNDCODE)
+			return
+		}
+
+		media.DeleteBlobs(req.Context(), a.Media, mediaDb)
+		resp.WriteHeader(NOCONTENTCODE)
+	})
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseChirpStatus(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		Status       string
+		PublishAt    string
+		ExpectedErr  bool
+		ExpectedTime time.Time
+	}{
+		{Status: CHIRP_PUBLISHED},
+		{Status: CHIRP_DRAFT},
+		{Status: CHIRP_SCHEDULED, PublishAt: "2026-03-01T13:00:00Z", ExpectedTime: now.Add(time.Hour)},
+		{Status: CHIRP_SCHEDULED, PublishAt: "2026-03-01T14:00:00+01:00", ExpectedTime: now.Add(time.Hour)},
+		{Status: CHIRP_SCHEDULED, PublishAt: "2026-03-01T12:00:00Z", ExpectedErr: true},
+		{Status: CHIRP_SCHEDULED, PublishAt: "2026-02-28T12:00:00Z", ExpectedErr: true},
+		{Status: CHIRP_SCHEDULED, PublishAt: "tomorrow", ExpectedErr: true},
+		{Status: CHIRP_SCHEDULED, ExpectedErr: true},
+		{Status: CHIRP_DRAFT, PublishAt: "2026-03-01T13:00:00Z", ExpectedErr: true},
+		{Status: "archived", ExpectedErr: true},
+		{Status: "", ExpectedErr: true},
+	}
+
+	for _, c := range cases {
+		status, publishAt, err := ParseChirpStatus(c.Status, c.PublishAt, now)
+		if (err != nil) != c.ExpectedErr {
+			t.Errorf("ParseChirpStatus(%q, %q) error = %v, expected error: %v", c.Status, c.PublishAt, err, c.ExpectedErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+
+		if status != c.Status {
+			t.Errorf("ParseChirpStatus(%q, %q) status = %q", c.Status, c.PublishAt, status)
+		}
+		if publishAt.Valid != !c.ExpectedTime.IsZero() || !publishAt.Time.Equal(c.ExpectedTime) {
+			t.Errorf("ParseChirpStatus(%q, %q) publish_at = %v, expected %v", c.Status, c.PublishAt, publishAt, c.ExpectedTime)
+		}
+	}
+}
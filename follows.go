@@ -0,0 +1,373 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shahanmmiah/Chirpy/internal/database"
+	"github.com/shahanmmiah/Chirpy/internal/pagination"
+)
+
+// ProfileJson is the public view of a user, so it leaves out the email.
+type ProfileJson struct {
+	ID           uuid.UUID `json:"id"`
+	CreatedAt    time.Time `json:"created_at"`
+	Username     string    `json:"username,omitempty"`
+	IsChirpyRed  bool      `json:"is_chirpy_red"`
+	Followers    int32     `json:"followers"`
+	Following    int32     `json:"following"`
+	FollowedByMe *bool     `json:"followed_by_me,omitempty"`
+}
+
+type FollowJson struct {
+	UserID      uuid.UUID `json:"user_id"`
+	Username    string    `json:"username,omitempty"`
+	IsChirpyRed bool      `json:"is_chirpy_red"`
+	FollowedAt  time.Time `json:"followed_at"`
+}
+
+// UserFromPath loads the user named by the {userID} path value, writing the
+// error response itself when that fails. Actions such as unblocking need the
+// user whatever the blocks; reads go through VisibleUserFromPath.
+func (a *ApiConfig) UserFromPath(resp http.ResponseWriter, req *http.Request) (database.User, bool) {
+	return a.userFromPath(resp, req, a.DbQueries.GetUserFromId)
+}
+
+// VisibleUserFromPath is UserFromPath for reads: a user who has blocked the
+// caller, or whom the caller has blocked, is not found.
+func (a *ApiConfig) VisibleUserFromPath(resp http.ResponseWriter, req *http.Request) (database.User, bool) {
+	return a.userFromPath(resp, req, func(ctx context.Context, id uuid.UUID) (database.User, error) {
+		return a.DbQueries.GetVisibleUser(ctx, database.GetVisibleUserParams{
+			ID:       id,
+			ViewerID: a.Viewer(req)})
+	})
+}
+
+func (a *ApiConfig) userFromPath(resp http.ResponseWriter, req *http.Request, get func(context.Context, uuid.UUID) (database.User, error)) (database.User, bool) {
+	id, err := uuid.Parse(req.PathValue("userID"))
+	if err != nil {
+		ErrorJsonResp(resp, fmt.Errorf("invalid user id: %v", err), FAILEDCODE)
+		return database.User{}, false
+	}
+
+	userDb, err := get(req.Context(), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		ErrorJsonResp(resp, fmt.Errorf("user %v not found", id), NOTFOUNDCODE)
+		return database.User{}, false
+	}
+	if err != nil {
+		ErrorJsonResp(resp, err, FAILEDCODE)
+		return database.User{}, false
+	}
+
+	return userDb, true
+}
+
+func (a *ApiConfig) MiddlewareGetProfile() http.Handler {
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		userDb, ok := a.VisibleUserFromPath(resp, req)
+		if !ok {
+			return
+		}
+
+		counts, err := a.DbQueries.GetFollowCounts(req.Context(), userDb.ID)
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		profile := ProfileJson{
+			ID:          userDb.ID,
+			CreatedAt:   userDb.CreatedAt,
+			Username:    userDb.Username.String,
+			IsChirpyRed: userDb.IsChirpyRed,
+			Followers:   counts.Followers,
+			Following:   counts.Following}
+
+		if viewer := a.Viewer(req); viewer.Valid {
+			following, err := a.DbQueries.IsFollowing(req.Context(), database.IsFollowingParams{
+				FollowerID: viewer.UUID,
+				FolloweeID: userDb.ID})
+
+			if err != nil {
+				ErrorJsonResp(resp, err, FAILEDCODE)
+				return
+			}
+			profile.FollowedByMe = &following
+		}
+
+		jsonData, err := json.Marshal(profile)
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		resp.Header().Set("Content-Type", "application/json")
+		resp.WriteHeader(OKCODE)
+		resp.Write(jsonData)
+	})
+}
+
+// AdjustFollowerCount moves a user's stored follower count by delta, and
+// lets the fan-out worker know in case that took them back under its limit.
+func (a *ApiConfig) AdjustFollowerCount(ctx context.Context, queries *database.Queries, userId uuid.UUID, delta int32) error {
+	count, err := queries.AdjustFollowerCount(ctx, database.AdjustFollowerCountParams{
+		Delta: delta,
+		ID:    userId})
+
+	if err != nil {
+		return err
+	}
+	return a.Fanout.FollowerCountChanged(ctx, queries, userId, count, delta)
+}
+
+func (a *ApiConfig) MiddlewareFollowUser() http.Handler {
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		userId, err := a.AuthenticatedUser(req)
+		if err != nil {
+			ErrorJsonResp(resp, err, UNAUTHORIZED)
+			return
+		}
+
+		userDb, ok := a.UserFromPath(resp, req)
+		if !ok {
+			return
+		}
+
+		if userDb.ID == userId {
+			ErrorJsonResp(resp, fmt.Errorf("cannot follow yourself"), FAILEDCODE)
+			return
+		}
+
+		blocked, err := a.DbQueries.IsBlockedBetween(req.Context(), database.IsBlockedBetweenParams{
+			UserA: userId,
+			UserB: userDb.ID})
+
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+		if blocked {
+			ErrorJsonResp(resp, fmt.Errorf("cannot follow user %v", userDb.ID), FORBIDDENCODE)
+			return
+		}
+
+		tx, err := a.Db.BeginTx(req.Context(), nil)
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+		defer tx.Rollback()
+
+		queries := a.DbQueries.WithTx(tx)
+
+		// following twice is not an error, it just changes nothing
+		added, err := queries.FollowUser(req.Context(), database.FollowUserParams{
+			FollowerID: userId,
+			FolloweeID: userDb.ID,
+			CreatedAt:  time.Now()})
+
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		if added != 0 {
+			err = a.AdjustFollowerCount(req.Context(), queries, userDb.ID, 1)
+			if err != nil {
+				ErrorJsonResp(resp, err, FAILEDCODE)
+				return
+			}
+
+			err = a.Fanout.Follow(req.Context(), queries, userId, userDb.ID)
+			if err != nil {
+				ErrorJsonResp(resp, err, FAILEDCODE)
+				return
+			}
+		}
+
+		err = tx.Commit()
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		a.Fanout.Wake()
+
+		resp.WriteHeader(NOCONTENTCODE)
+	})
+}
+
+func (a *ApiConfig) MiddlewareUnfollowUser() http.Handler {
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		userId, err := a.AuthenticatedUser(req)
+		if err != nil {
+			ErrorJsonResp(resp, err, UNAUTHORIZED)
+			return
+		}
+
+		userDb, ok := a.UserFromPath(resp, req)
+		if !ok {
+			return
+		}
+
+		tx, err := a.Db.BeginTx(req.Context(), nil)
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+		defer tx.Rollback()
+
+		queries := a.DbQueries.WithTx(tx)
+
+		removed, err := queries.UnfollowUser(req.Context(), database.UnfollowUserParams{
+			FollowerID: userId,
+			FolloweeID: userDb.ID})
+
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		if removed != 0 {
+			err = a.AdjustFollowerCount(req.Context(), queries, userDb.ID, -1)
+			if err != nil {
+				ErrorJsonResp(resp, err, FAILEDCODE)
+				return
+			}
+
+			err = a.Fanout.Unfollow(req.Context(), queries, userId, userDb.ID)
+			if err != nil {
+				ErrorJsonResp(resp, err, FAILEDCODE)
+				return
+			}
+		}
+
+		err = tx.Commit()
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		a.Fanout.Wake()
+
+		resp.WriteHeader(NOCONTENTCODE)
+	})
+}
+
+func (a *ApiConfig) MiddlewareGetFollowers() http.Handler {
+	return a.followListHandler(func(req *http.Request, userId uuid.UUID, page pagination.Page) ([]FollowJson, error) {
+		rows, err := a.DbQueries.ListFollowers(req.Context(), database.ListFollowersParams{
+			UserID:          userId,
+			ViewerID:        a.Viewer(req),
+			CursorCreatedAt: page.CursorCreatedAt(),
+			CursorID:        page.CursorID(),
+			RowLimit:        page.FetchLimit()})
+
+		follows := []FollowJson{}
+		for _, r := range rows {
+			follows = append(follows, FollowDbToJson(r.User, r.FollowedAt))
+		}
+		return follows, err
+	})
+}
+
+func (a *ApiConfig) MiddlewareGetFollowing() http.Handler {
+	return a.followListHandler(func(req *http.Request, userId uuid.UUID, page pagination.Page) ([]FollowJson, error) {
+		rows, err := a.DbQueries.ListFollowing(req.Context(), database.ListFollowingParams{
+			UserID:          userId,
+			ViewerID:        a.Viewer(req),
+			CursorCreatedAt: page.CursorCreatedAt(),
+			CursorID:        page.CursorID(),
+			RowLimit:        page.FetchLimit()})
+
+		follows := []FollowJson{}
+		for _, r := range rows {
+			follows = append(follows, FollowDbToJson(r.User, r.FollowedAt))
+		}
+		return follows, err
+	})
+}
+
+func FollowDbToJson(userDb database.User, followedAt time.Time) FollowJson {
+	return FollowJson{
+		UserID:      userDb.ID,
+		Username:    userDb.Username.String,
+		IsChirpyRed: userDb.IsChirpyRed,
+		FollowedAt:  followedAt}
+}
+
+// followListHandler pages through one side of a user's follow graph, newest
+// follow first.
+func (a *ApiConfig) followListHandler(list func(*http.Request, uuid.UUID, pagination.Page) ([]FollowJson, error)) http.Handler {
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		userDb, ok := a.VisibleUserFromPath(resp, req)
+		if !ok {
+			return
+		}
+
+		page, err := pagination.FromQuery(req.URL.Query())
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		follows, err := list(req, userDb.ID, page)
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		if len(follows) > int(page.Limit) {
+			follows = follows[:page.Limit]
+			last := follows[len(follows)-1]
+			resp.Header().Set("Link", pagination.NextLink(req.URL, pagination.Cursor{CreatedAt: last.FollowedAt, ID: last.UserID}.Encode()))
+		}
+
+		jsonData, err := json.Marshal(follows)
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		resp.Header().Set("Content-Type", "application/json")
+		resp.WriteHeader(OKCODE)
+		resp.Write(jsonData)
+	})
+}
+
+func (a *ApiConfig) MiddlewareGetHomeTimeline() http.Handler {
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		userId, err := a.AuthenticatedUser(req)
+		if err != nil {
+			ErrorJsonResp(resp, err, UNAUTHORIZED)
+			return
+		}
+
+		page, err := pagination.FromQuery(req.URL.Query())
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		chirpsDb, err := a.DbQueries.ListMaterialisedHomeTimeline(req.Context(), database.ListMaterialisedHomeTimelineParams{
+			UserID:          userId,
+			CursorCreatedAt: page.CursorCreatedAt(),
+			CursorID:        page.CursorID(),
+			RowLimit:        page.FetchLimit(),
+			FanoutLimit:     a.Fanout.Limit()})
+
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		a.WriteChirpPage(resp, req, page, chirpsDb)
+	})
+}
@@ -1,7 +1,11 @@
 package auth
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
+	"net/http"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -42,7 +46,7 @@ func MakeJWT(userId uuid.UUID, tokenSecret string, expires time.Duration) (strin
 func ValidateJWT(signedToken, tokenSecret string) (uuid.UUID, error) {
 	token, err := jwt.ParseWithClaims(signedToken, &jwt.RegisteredClaims{}, func(token *jwt.Token) (any, error) {
 		return []byte(tokenSecret), nil
-	})
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithIssuer("Chirpy"))
 
 	if err != nil {
 		return uuid.UUID{}, err
@@ -59,3 +63,43 @@ func ValidateJWT(signedToken, tokenSecret string) (uuid.UUID, error) {
 	}
 	return id, nil
 }
+
+func GetBearerToken(headers http.Header) (string, error) {
+	authHeader := headers.Get("Authorization")
+	if authHeader == "" {
+		return "", fmt.Errorf("authorization header missing")
+	}
+
+	token, found := strings.CutPrefix(authHeader, "Bearer ")
+	token = strings.TrimSpace(token)
+	if !found || token == "" {
+		return "", fmt.Errorf("authorization header is not a bearer token")
+	}
+
+	return token, nil
+}
+
+func MakeRefreshToken() (string, error) {
+	tokenData := make([]byte, 32)
+	_, err := rand.Read(tokenData)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(tokenData), nil
+}
+
+func GetAPIKey(headers http.Header) (string, error) {
+	authHeader := headers.Get("Authorization")
+	if authHeader == "" {
+		return "", fmt.Errorf("authorization header missing")
+	}
+
+	key, found := strings.CutPrefix(authHeader, "ApiKey ")
+	key = strings.TrimSpace(key)
+	if !found || key == "" {
+		return "", fmt.Errorf("authorization header is not an api key")
+	}
+
+	return key, nil
+}
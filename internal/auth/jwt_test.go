@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"net/http"
 	"strings"
 	"testing"
 	"time"
@@ -116,3 +117,112 @@ func TestJwtExpires(t *testing.T) {
 
 	}
 }
+
+func TestGetBearerToken(t *testing.T) {
+	cases := []struct {
+		InputHeader   string
+		ExpectedToken string
+		ExpectErr     bool
+	}{
+		{
+			InputHeader:   "Bearer abc.def.ghi",
+			ExpectedToken: "abc.def.ghi"},
+		{
+			InputHeader: "",
+			ExpectErr:   true},
+		{
+			InputHeader: "Basic dXNlcjpwYXNz",
+			ExpectErr:   true},
+		{
+			InputHeader: "Bearer ",
+			ExpectErr:   true},
+	}
+	for _, c := range cases {
+
+		headers := http.Header{}
+		if c.InputHeader != "" {
+			headers.Set("Authorization", c.InputHeader)
+		}
+
+		token, err := GetBearerToken(headers)
+
+		if c.ExpectErr {
+			if err == nil {
+				t.Errorf("error should not be nil for header '%s'", c.InputHeader)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("error getting bearer token: %s", err.Error())
+		}
+
+		if token != c.ExpectedToken {
+			t.Errorf("error token is '%s' should be '%s'", token, c.ExpectedToken)
+		}
+
+	}
+}
+
+func TestMakeRefreshToken(t *testing.T) {
+	first, err := MakeRefreshToken()
+	if err != nil {
+		t.Errorf("error making refresh token: %s", err.Error())
+	}
+
+	second, err := MakeRefreshToken()
+	if err != nil {
+		t.Errorf("error making refresh token: %s", err.Error())
+	}
+
+	if len(first) != 64 {
+		t.Errorf("error refresh token length is %d should be 64", len(first))
+	}
+
+	if first == second {
+		t.Error("error refresh tokens should not repeat")
+	}
+}
+
+func TestGetAPIKey(t *testing.T) {
+	cases := []struct {
+		InputHeader string
+		ExpectedKey string
+		ExpectErr   bool
+	}{
+		{
+			InputHeader: "ApiKey f271c81ff7084ee5b99a5091b42d486e",
+			ExpectedKey: "f271c81ff7084ee5b99a5091b42d486e"},
+		{
+			InputHeader: "",
+			ExpectErr:   true},
+		{
+			InputHeader: "Bearer f271c81ff7084ee5b99a5091b42d486e",
+			ExpectErr:   true},
+	}
+	for _, c := range cases {
+
+		headers := http.Header{}
+		if c.InputHeader != "" {
+			headers.Set("Authorization", c.InputHeader)
+		}
+
+		key, err := GetAPIKey(headers)
+
+		if c.ExpectErr {
+			if err == nil {
+				t.Errorf("error should not be nil for header '%s'", c.InputHeader)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("error getting api key: %s", err.Error())
+		}
+
+		if key != c.ExpectedKey {
+			t.Errorf("error key is '%s' should be '%s'", key, c.ExpectedKey)
+		}
+
+	}
+}
@@ -0,0 +1,108 @@
+package chirptext
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Span is a byte range [Start, End) of a chirp body.
+type Span struct {
+	Start int
+	End   int
+	Text  string
+}
+
+var urlPattern = regexp.MustCompile(`(?i)\bhttps?://[^\s<>"]+`)
+
+// URLs finds the http(s) links in text, leaving off trailing punctuation
+// that belongs to the sentence rather than the link.
+func URLs(text string) []Span {
+	spans := []Span{}
+	for _, loc := range urlPattern.FindAllStringIndex(text, -1) {
+		link := strings.TrimRight(text[loc[0]:loc[1]], `.,!?;:'")]`)
+		spans = append(spans, Span{Start: loc[0], End: loc[0] + len(link), Text: link})
+	}
+	return spans
+}
+
+const MaxTagLen = 64
+
+func isTagRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r) || r == '_'
+}
+
+// prefixed finds words introduced by marker ('#' or '@'). The marker must not
+// follow a word character, so "a#b" and "me@example.com" are not matched,
+// and the word must contain a letter so "#1" is not a tag.
+func prefixed(text string, marker rune, accept func(word string) bool) []Span {
+	spans := []Span{}
+	prev := rune(-1)
+
+	for i, r := range text {
+		if r != marker || (prev >= 0 && (isTagRune(prev) || prev == marker)) {
+			prev = r
+			continue
+		}
+		prev = r
+
+		end := i + utf8.RuneLen(marker)
+		hasLetter := false
+		for _, w := range text[end:] {
+			if !isTagRune(w) {
+				break
+			}
+			hasLetter = hasLetter || unicode.IsLetter(w)
+			end += utf8.RuneLen(w)
+		}
+
+		word := text[i+utf8.RuneLen(marker) : end]
+		if hasLetter && accept(word) {
+			spans = append(spans, Span{Start: i, End: end, Text: word})
+		}
+	}
+	return spans
+}
+
+// Hashtags finds the #tags in text. Span.Text holds the tag without its '#'.
+func Hashtags(text string) []Span {
+	return prefixed(text, '#', func(word string) bool {
+		return utf8.RuneCountInString(word) <= MaxTagLen
+	})
+}
+
+// TagNames returns the distinct tags in text, lower-cased, in order of
+// first appearance.
+func TagNames(text string) []string {
+	names := []string{}
+	seen := map[string]bool{}
+	for _, tag := range Hashtags(text) {
+		name := strings.ToLower(tag.Text)
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+const MaxUsernameLen = 15
+
+var usernamePattern = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+func IsValidUsername(name string) bool {
+	return len(name) <= MaxUsernameLen && usernamePattern.MatchString(name)
+}
+
+// Mentions finds the @handles in text. Span.Text holds the handle without
+// its '@'.
+func Mentions(text string) []Span {
+	return prefixed(text, '@', IsValidUsername)
+}
+
+// RuneOffsets converts a byte span to the code point offset and length that
+// clients see, including the span's '#' or '@' marker.
+func RuneOffsets(text string, span Span) (int, int) {
+	return utf8.RuneCountInString(text[:span.Start]), utf8.RuneCountInString(text[span.Start:span.End])
+}
@@ -0,0 +1,96 @@
+package chirptext
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestHashtags(t *testing.T) {
+	cases := []struct {
+		InputText    string
+		ExpectedTags []string
+	}{
+		{
+			InputText:    "learning #golang with #BootDev",
+			ExpectedTags: []string{"golang", "BootDev"}},
+		{
+			InputText:    "#start, middle #tag. end#nope",
+			ExpectedTags: []string{"start", "tag"}},
+		{
+			InputText:    "number #1 and ##double and #snake_case",
+			ExpectedTags: []string{"snake_case"}},
+		{
+			InputText:    "unicode #café #日本",
+			ExpectedTags: []string{"café", "日本"}},
+		{
+			InputText:    "no tags here",
+			ExpectedTags: []string{}},
+	}
+	for _, c := range cases {
+
+		actual := []string{}
+		for _, span := range Hashtags(c.InputText) {
+			actual = append(actual, span.Text)
+			if c.InputText[span.Start:span.End] != "#"+span.Text {
+				t.Errorf("error span %v does not cover '#%s'", span, span.Text)
+			}
+		}
+
+		if !slices.Equal(actual, c.ExpectedTags) {
+			t.Errorf("error tags are %v should be %v", actual, c.ExpectedTags)
+		}
+
+	}
+}
+
+func TestTagNames(t *testing.T) {
+	actual := TagNames("#Go #go #GO #rust")
+	expected := []string{"go", "rust"}
+
+	if !slices.Equal(actual, expected) {
+		t.Errorf("error tag names are %v should be %v", actual, expected)
+	}
+}
+
+func TestMentions(t *testing.T) {
+	cases := []struct {
+		InputText       string
+		ExpectedHandles []string
+		ExpectedOffsets []int
+	}{
+		{
+			InputText:       "hey @alice and @Bob_99!",
+			ExpectedHandles: []string{"alice", "Bob_99"},
+			ExpectedOffsets: []int{4, 15}},
+		{
+			InputText:       "mail me@example.com or @@double",
+			ExpectedHandles: []string{},
+			ExpectedOffsets: []int{}},
+		{
+			InputText:       "🎉 @party_person_handle_too_long @ok",
+			ExpectedHandles: []string{"ok"},
+			ExpectedOffsets: []int{32}},
+	}
+	for _, c := range cases {
+
+		handles := []string{}
+		offsets := []int{}
+		for _, span := range Mentions(c.InputText) {
+			handles = append(handles, span.Text)
+			offset, length := RuneOffsets(c.InputText, span)
+			offsets = append(offsets, offset)
+			if length != len(span.Text)+1 {
+				t.Errorf("error length is %d should be %d", length, len(span.Text)+1)
+			}
+		}
+
+		if !slices.Equal(handles, c.ExpectedHandles) {
+			t.Errorf("error handles are %v should be %v", handles, c.ExpectedHandles)
+		}
+
+		if !slices.Equal(offsets, c.ExpectedOffsets) {
+			t.Errorf("error offsets are %v should be %v", offsets, c.ExpectedOffsets)
+		}
+
+	}
+}
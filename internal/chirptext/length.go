@@ -0,0 +1,118 @@
+package chirptext
+
+import (
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/rivo/uniseg"
+)
+
+const zwj = '\u200d'
+
+// conjunctConsonants are the Indic_Conjunct_Break=Consonant ranges from
+// Unicode 15.1: the consonants of the six scripts whose viramas join
+// conjuncts.
+var conjunctConsonants = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{Lo: 0x0915, Hi: 0x0939, Stride: 1},
+		{Lo: 0x0958, Hi: 0x095f, Stride: 1},
+		{Lo: 0x0978, Hi: 0x097f, Stride: 1},
+		{Lo: 0x0995, Hi: 0x09a8, Stride: 1},
+		{Lo: 0x09aa, Hi: 0x09b0, Stride: 1},
+		{Lo: 0x09b2, Hi: 0x09b2, Stride: 1},
+		{Lo: 0x09b6, Hi: 0x09b9, Stride: 1},
+		{Lo: 0x09dc, Hi: 0x09dd, Stride: 1},
+		{Lo: 0x09df, Hi: 0x09df, Stride: 1},
+		{Lo: 0x09f0, Hi: 0x09f1, Stride: 1},
+		{Lo: 0x0a95, Hi: 0x0aa8, Stride: 1},
+		{Lo: 0x0aaa, Hi: 0x0ab0, Stride: 1},
+		{Lo: 0x0ab2, Hi: 0x0ab3, Stride: 1},
+		{Lo: 0x0ab5, Hi: 0x0ab9, Stride: 1},
+		{Lo: 0x0af9, Hi: 0x0af9, Stride: 1},
+		{Lo: 0x0b15, Hi: 0x0b28, Stride: 1},
+		{Lo: 0x0b2a, Hi: 0x0b30, Stride: 1},
+		{Lo: 0x0b32, Hi: 0x0b33, Stride: 1},
+		{Lo: 0x0b35, Hi: 0x0b39, Stride: 1},
+		{Lo: 0x0b5c, Hi: 0x0b5d, Stride: 1},
+		{Lo: 0x0b5f, Hi: 0x0b5f, Stride: 1},
+		{Lo: 0x0b71, Hi: 0x0b71, Stride: 1},
+		{Lo: 0x0c15, Hi: 0x0c28, Stride: 1},
+		{Lo: 0x0c2a, Hi: 0x0c39, Stride: 1},
+		{Lo: 0x0c58, Hi: 0x0c5a, Stride: 1},
+		{Lo: 0x0d15, Hi: 0x0d3a, Stride: 1},
+	},
+}
+
+// isConjunctLinker is Indic_Conjunct_Break=Linker: the viramas of those
+// scripts.
+func isConjunctLinker(r rune) bool {
+	switch r {
+	case 0x094d, 0x09cd, 0x0acd, 0x0b4d, 0x0c4d, 0x0d4d:
+		return true
+	}
+	return false
+}
+
+// endsInConjunct reports whether cluster is a consonant followed only by
+// marks, at least one of them a virama, so that a following consonant
+// belongs to the same conjunct.
+func endsInConjunct(cluster string) bool {
+	linked := false
+	for len(cluster) > 0 {
+		r, size := utf8.DecodeLastRuneInString(cluster)
+		switch {
+		case isConjunctLinker(r):
+			linked = true
+		case r == zwj || unicode.In(r, unicode.Mn):
+		case unicode.Is(conjunctConsonants, r):
+			return linked
+		default:
+			return false
+		}
+		cluster = cluster[:len(cluster)-size]
+	}
+	return false
+}
+
+// Graphemes counts user-perceived characters: the extended grapheme clusters
+// of UAX #29. uniseg implements the rules as of Unicode 15.0; the Indic
+// conjunct rule (GB9c) added in 15.1 is applied on top, so a conjunct like
+// क्ष counts once.
+func Graphemes(text string) int {
+	count := 0
+	state := -1
+	prev := ""
+
+	for text != "" {
+		var cluster string
+		cluster, text, _, state = uniseg.FirstGraphemeClusterInString(text, state)
+
+		first, _ := utf8.DecodeRuneInString(cluster)
+		if prev != "" && endsInConjunct(prev) && unicode.Is(conjunctConsonants, first) {
+			prev += cluster
+			continue
+		}
+
+		count++
+		prev = cluster
+	}
+
+	return count
+}
+
+// Length measures a chirp against its limit. When urlWeight is positive every
+// link counts as that many characters no matter how long it is.
+func Length(text string, urlWeight int) int {
+	if urlWeight <= 0 {
+		return Graphemes(text)
+	}
+
+	length := 0
+	last := 0
+	for _, link := range URLs(text) {
+		length += Graphemes(text[last:link.Start]) + urlWeight
+		last = link.End
+	}
+
+	return length + Graphemes(text[last:])
+}
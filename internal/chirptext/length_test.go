@@ -0,0 +1,126 @@
+package chirptext
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGraphemes(t *testing.T) {
+	cases := []struct {
+		InputText     string
+		ExpectedCount int
+	}{
+		{
+			InputText:     "",
+			ExpectedCount: 0},
+		{
+			InputText:     "hello",
+			ExpectedCount: 5},
+		{
+			InputText:     "café",
+			ExpectedCount: 4},
+		{
+			InputText:     "cafe\u0301",
+			ExpectedCount: 4},
+		{
+			InputText:     "👍🏽",
+			ExpectedCount: 1},
+		{
+			InputText:     "👨‍👩‍👧‍👦",
+			ExpectedCount: 1},
+		{
+			InputText:     "🇬🇧🇯🇵",
+			ExpectedCount: 2},
+		{
+			InputText:     "❤️",
+			ExpectedCount: 1},
+		{
+			InputText:     "a\r\nb",
+			ExpectedCount: 3},
+		{
+			InputText:     "日本語",
+			ExpectedCount: 3},
+		{
+			// Hangul jamo: two syllables spelled out as L V T and L V
+			InputText:     "\u1112\u1161\u11ab\u1100\u1173",
+			ExpectedCount: 2},
+		{
+			InputText:     "한국어",
+			ExpectedCount: 3},
+		{
+			// Prepend: an Arabic number sign joins the digits after it
+			InputText:     "\u0600\u0661\u0662",
+			ExpectedCount: 2},
+		{
+			// Devanagari: the conjunct क्ष plus a vowel sign is one cluster
+			InputText:     "क्षि",
+			ExpectedCount: 1},
+		{
+			InputText:     "हिन्दी",
+			ExpectedCount: 2},
+		{
+			// a chain of two viramas still makes one conjunct
+			InputText:     "स्त्र",
+			ExpectedCount: 1},
+		{
+			// Tamil is not one of the conjunct-joining scripts
+			InputText:     "க்ஷ",
+			ExpectedCount: 2},
+	}
+	for _, c := range cases {
+
+		actual := Graphemes(c.InputText)
+		if actual != c.ExpectedCount {
+			t.Errorf("error '%s' counts %d should be %d", c.InputText, actual, c.ExpectedCount)
+		}
+
+	}
+}
+
+func TestLength(t *testing.T) {
+	longUrl := "https://example.com/" + strings.Repeat("a", 200)
+
+	cases := []struct {
+		InputText      string
+		InputUrlWeight int
+		ExpectedLength int
+	}{
+		{
+			InputText:      "see " + longUrl,
+			InputUrlWeight: 0,
+			ExpectedLength: 4 + len(longUrl)},
+		{
+			InputText:      "see " + longUrl,
+			InputUrlWeight: 23,
+			ExpectedLength: 4 + 23},
+		{
+			InputText:      "(http://a.io) and https://b.io.",
+			InputUrlWeight: 10,
+			ExpectedLength: 1 + 10 + 6 + 10 + 1},
+		{
+			InputText:      "no links 🎉",
+			InputUrlWeight: 23,
+			ExpectedLength: 10},
+	}
+	for _, c := range cases {
+
+		actual := Length(c.InputText, c.InputUrlWeight)
+		if actual != c.ExpectedLength {
+			t.Errorf("error '%s' measures %d should be %d", c.InputText, actual, c.ExpectedLength)
+		}
+
+	}
+}
+
+func TestURLs(t *testing.T) {
+	spans := URLs("read https://boot.dev/learn, then http://x.io/a?b=c!")
+
+	if len(spans) != 2 {
+		t.Errorf("error found %d urls should be 2", len(spans))
+		return
+	}
+
+	if spans[0].Text != "https://boot.dev/learn" || spans[1].Text != "http://x.io/a?b=c" {
+		t.Errorf("error unexpected urls %v", spans)
+	}
+}
@@ -7,29 +7,42 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const createChirps = `-- name: CreateChirps :one
-INSERT INTO chirps(id, created_at, updated_at, body, user_id)
+INSERT INTO chirps(id, created_at, updated_at, body, user_id, body_tsv, in_reply_to, chirp_kind, ref_chirp_id, status, publish_at)
 VALUES (
     $1,
     $2,
     $3,
     $4,
-    $5
+    $5,
+    $6,
+    $7,
+    $8,
+    $9,
+    $10
 )
-RETURNING id, created_at, updated_at, body, user_id
+RETURNING id, created_at, updated_at, body, user_id, body_tsv, in_reply_to, like_count, chirp_kind, ref_chirp_id, status, publish_at, edited_at, deleted_at
 `
 
 type CreateChirpsParams struct {
-	ID        uuid.UUID
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	Body      string
-	UserID    uuid.UUID
+	ID         uuid.UUID
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	Body       string
+	UserID     uuid.UUID
+	BodyTsv    interface{}
+	InReplyTo  uuid.NullUUID
+	ChirpKind  string
+	RefChirpID uuid.NullUUID
+	Status     string
+	PublishAt  sql.NullTime
 }
 
 func (q *Queries) CreateChirps(ctx context.Context, arg CreateChirpsParams) (Chirp, error) {
@@ -39,6 +52,11 @@ func (q *Queries) CreateChirps(ctx context.Context, arg CreateChirpsParams) (Chi
 		arg.UpdatedAt,
 		arg.Body,
 		arg.UserID,
+		arg.InReplyTo,
+		arg.ChirpKind,
+		arg.RefChirpID,
+		arg.Status,
+		arg.PublishAt,
 	)
 	var i Chirp
 	err := row.Scan(
@@ -47,12 +65,35 @@ func (q *Queries) CreateChirps(ctx context.Context, arg CreateChirpsParams) (Chi
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.BodyTsv,
+		&i.InReplyTo,
+		&i.LikeCount,
+		&i.ChirpKind,
+		&i.RefChirpID,
+		&i.Status,
+		&i.PublishAt,
+		&i.EditedAt,
+		&i.DeletedAt,
 	)
 	return i, err
 }
 
+const deleteChirp = `-- name: DeleteChirp :exec
+DELETE FROM chirps WHERE id = $1 AND user_id = $2
+`
+
+type DeleteChirpParams struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+}
+
+func (q *Queries) DeleteChirp(ctx context.Context, arg DeleteChirpParams) error {
+	_, err := q.db.ExecContext(ctx, deleteChirp, arg.ID, arg.UserID)
+	return err
+}
+
 const getAllChirps = `-- name: GetAllChirps :many
-SELECT id, created_at, updated_at, body, user_id FROM chirps ORDER BY created_at ASC
+SELECT id, created_at, updated_at, body, user_id, body_tsv, in_reply_to, like_count, chirp_kind, ref_chirp_id, status, publish_at, edited_at, deleted_at FROM chirps WHERE status = 'published' AND deleted_at IS NULL ORDER BY created_at ASC
 `
 
 func (q *Queries) GetAllChirps(ctx context.Context) ([]Chirp, error) {
@@ -70,6 +111,15 @@ func (q *Queries) GetAllChirps(ctx context.Context) ([]Chirp, error) {
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.BodyTsv,
+			&i.InReplyTo,
+			&i.LikeCount,
+			&i.ChirpKind,
+			&i.RefChirpID,
+			&i.Status,
+			&i.PublishAt,
+			&i.EditedAt,
+			&i.DeletedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -85,7 +135,7 @@ func (q *Queries) GetAllChirps(ctx context.Context) ([]Chirp, error) {
 }
 
 const getChirps = `-- name: GetChirps :one
-SELECT id, created_at, updated_at, body, user_id FROM chirps WHERE id = $1 LIMIT 1
+SELECT id, created_at, updated_at, body, user_id, body_tsv, in_reply_to, like_count, chirp_kind, ref_chirp_id, status, publish_at, edited_at, deleted_at FROM chirps WHERE id = $1 LIMIT 1
 `
 
 func (q *Queries) GetChirps(ctx context.Context, id uuid.UUID) (Chirp, error) {
@@ -97,10 +147,271 @@ func (q *Queries) GetChirps(ctx context.Context, id uuid.UUID) (Chirp, error) {
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.BodyTsv,
+		&i.InReplyTo,
+		&i.LikeCount,
+		&i.ChirpKind,
+		&i.RefChirpID,
+		&i.Status,
+		&i.PublishAt,
+		&i.EditedAt,
+		&i.DeletedAt,
 	)
 	return i, err
 }
 
+const getChirpsByIds = `-- name: GetChirpsByIds :many
+SELECT id, created_at, updated_at, body, user_id, body_tsv, in_reply_to, like_count, chirp_kind, ref_chirp_id, status, publish_at, edited_at, deleted_at FROM chirps
+WHERE id = ANY($1::UUID[])
+AND status = 'published' AND deleted_at IS NULL
+AND NOT is_blocked_between($2::UUID, chirps.user_id)
+`
+
+type GetChirpsByIdsParams struct {
+	Ids      []uuid.UUID
+	ViewerID uuid.NullUUID
+}
+
+func (q *Queries) GetChirpsByIds(ctx context.Context, arg GetChirpsByIdsParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsByIds, pq.Array(arg.Ids), arg.ViewerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.BodyTsv,
+			&i.InReplyTo,
+			&i.LikeCount,
+			&i.ChirpKind,
+			&i.RefChirpID,
+			&i.Status,
+			&i.PublishAt,
+			&i.EditedAt,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getVisibleChirp = `-- name: GetVisibleChirp :one
+SELECT id, created_at, updated_at, body, user_id, body_tsv, in_reply_to, like_count, chirp_kind, ref_chirp_id, status, publish_at, edited_at, deleted_at FROM chirps
+WHERE id = $1
+AND status = 'published' AND deleted_at IS NULL
+AND NOT is_blocked_between($2::UUID, chirps.user_id)
+LIMIT 1
+`
+
+type GetVisibleChirpParams struct {
+	ID       uuid.UUID
+	ViewerID uuid.NullUUID
+}
+
+func (q *Queries) GetVisibleChirp(ctx context.Context, arg GetVisibleChirpParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, getVisibleChirp, arg.ID, arg.ViewerID)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.BodyTsv,
+		&i.InReplyTo,
+		&i.LikeCount,
+		&i.ChirpKind,
+		&i.RefChirpID,
+		&i.Status,
+		&i.PublishAt,
+		&i.EditedAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const listChirpsAsc = `-- name: ListChirpsAsc :many
+SELECT id, created_at, updated_at, body, user_id, body_tsv, in_reply_to, like_count, chirp_kind, ref_chirp_id, status, publish_at, edited_at, deleted_at FROM chirps
+WHERE ($1::UUID IS NULL OR user_id = $1::UUID)
+AND status = 'published' AND deleted_at IS NULL
+AND NOT is_blocked_between($2::UUID, chirps.user_id)
+AND NOT EXISTS (
+    SELECT 1 FROM mutes
+    WHERE mutes.muter_id = $2::UUID AND mutes.muted_id = chirps.user_id)
+AND ($3::TIMESTAMP IS NULL
+    OR (created_at, id) > ($3::TIMESTAMP, $4::UUID))
+ORDER BY created_at ASC, id ASC
+LIMIT $5
+`
+
+type ListChirpsAscParams struct {
+	AuthorID        uuid.NullUUID
+	ViewerID        uuid.NullUUID
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	RowLimit        int32
+}
+
+func (q *Queries) ListChirpsAsc(ctx context.Context, arg ListChirpsAscParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, listChirpsAsc,
+		arg.AuthorID,
+		arg.ViewerID,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.BodyTsv,
+			&i.InReplyTo,
+			&i.LikeCount,
+			&i.ChirpKind,
+			&i.RefChirpID,
+			&i.Status,
+			&i.PublishAt,
+			&i.EditedAt,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listChirpsDesc = `-- name: ListChirpsDesc :many
+SELECT id, created_at, updated_at, body, user_id, body_tsv, in_reply_to, like_count, chirp_kind, ref_chirp_id, status, publish_at, edited_at, deleted_at FROM chirps
+WHERE ($1::UUID IS NULL OR user_id = $1::UUID)
+AND status = 'published' AND deleted_at IS NULL
+AND NOT is_blocked_between($2::UUID, chirps.user_id)
+AND NOT EXISTS (
+    SELECT 1 FROM mutes
+    WHERE mutes.muter_id = $2::UUID AND mutes.muted_id = chirps.user_id)
+AND ($3::TIMESTAMP IS NULL
+    OR (created_at, id) < ($3::TIMESTAMP, $4::UUID))
+ORDER BY created_at DESC, id DESC
+LIMIT $5
+`
+
+type ListChirpsDescParams struct {
+	AuthorID        uuid.NullUUID
+	ViewerID        uuid.NullUUID
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	RowLimit        int32
+}
+
+func (q *Queries) ListChirpsDesc(ctx context.Context, arg ListChirpsDescParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, listChirpsDesc,
+		arg.AuthorID,
+		arg.ViewerID,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.BodyTsv,
+			&i.InReplyTo,
+			&i.LikeCount,
+			&i.ChirpKind,
+			&i.RefChirpID,
+			&i.Status,
+			&i.PublishAt,
+			&i.EditedAt,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDeletedChirpIds = `-- name: ListDeletedChirpIds :many
+SELECT id FROM chirps
+WHERE id = ANY($1::UUID[])
+AND deleted_at IS NOT NULL
+AND NOT is_blocked_between($2::UUID, chirps.user_id)
+`
+
+type ListDeletedChirpIdsParams struct {
+	Ids      []uuid.UUID
+	ViewerID uuid.NullUUID
+}
+
+func (q *Queries) ListDeletedChirpIds(ctx context.Context, arg ListDeletedChirpIdsParams) ([]uuid.UUID, error) {
+	rows, err := q.db.QueryContext(ctx, listDeletedChirpIds, pq.Array(arg.Ids), arg.ViewerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const resetChirps = `-- name: ResetChirps :exec
 DELETE FROM chirps
 `
@@ -109,3 +420,169 @@ func (q *Queries) ResetChirps(ctx context.Context) error {
 	_, err := q.db.ExecContext(ctx, resetChirps)
 	return err
 }
+
+const restoreChirp = `-- name: RestoreChirp :one
+UPDATE chirps
+SET deleted_at = NULL
+WHERE id = $1 AND user_id = $2 AND deleted_at >= $3
+RETURNING id, created_at, updated_at, body, user_id, body_tsv, in_reply_to, like_count, chirp_kind, ref_chirp_id, status, publish_at, edited_at, deleted_at
+`
+
+type RestoreChirpParams struct {
+	ID           uuid.UUID
+	UserID       uuid.UUID
+	DeletedSince sql.NullTime
+}
+
+func (q *Queries) RestoreChirp(ctx context.Context, arg RestoreChirpParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, restoreChirp, arg.ID, arg.UserID, arg.DeletedSince)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.BodyTsv,
+		&i.InReplyTo,
+		&i.LikeCount,
+		&i.ChirpKind,
+		&i.RefChirpID,
+		&i.Status,
+		&i.PublishAt,
+		&i.EditedAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const restoreRechirpsOf = `-- name: RestoreRechirpsOf :exec
+UPDATE chirps
+SET deleted_at = NULL
+WHERE ref_chirp_id = $1 AND chirp_kind = 'rechirp' AND deleted_at = $2
+`
+
+type RestoreRechirpsOfParams struct {
+	RefChirpID uuid.NullUUID
+	DeletedAt  sql.NullTime
+}
+
+func (q *Queries) RestoreRechirpsOf(ctx context.Context, arg RestoreRechirpsOfParams) error {
+	_, err := q.db.ExecContext(ctx, restoreRechirpsOf, arg.RefChirpID, arg.DeletedAt)
+	return err
+}
+
+const searchChirps = `-- name: SearchChirps :many
+SELECT chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.body_tsv, chirps.in_reply_to, chirps.like_count, chirps.chirp_kind, chirps.ref_chirp_id, chirps.status, chirps.publish_at, chirps.edited_at, chirps.deleted_at,
+    ts_rank(chirps.body_tsv, query)::REAL AS rank,
+    ts_headline('english',
+        replace(replace(replace(replace(chirps.body, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), '"', '&quot;'),
+        query, 'StartSel=<mark>, StopSel=</mark>, HighlightAll=true')::TEXT AS headline
+FROM chirps, to_tsquery('english', $1::TEXT) query
+WHERE chirps.body_tsv @@ query
+AND chirps.status = 'published' AND chirps.deleted_at IS NULL
+AND ($2::UUID IS NULL OR chirps.user_id = $2::UUID)
+AND NOT is_blocked_between($3::UUID, chirps.user_id)
+AND ($4::REAL IS NULL
+    OR (ts_rank(chirps.body_tsv, query)::REAL, chirps.id) < ($4::REAL, $5::UUID))
+ORDER BY rank DESC, chirps.id DESC
+LIMIT $6
+`
+
+type SearchChirpsParams struct {
+	Query      string
+	AuthorID   uuid.NullUUID
+	ViewerID   uuid.NullUUID
+	CursorRank sql.NullFloat64
+	CursorID   uuid.NullUUID
+	RowLimit   int32
+}
+
+type SearchChirpsRow struct {
+	Chirp    Chirp
+	Rank     float32
+	Headline string
+}
+
+func (q *Queries) SearchChirps(ctx context.Context, arg SearchChirpsParams) ([]SearchChirpsRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchChirps,
+		arg.Query,
+		arg.AuthorID,
+		arg.ViewerID,
+		arg.CursorRank,
+		arg.CursorID,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchChirpsRow
+	for rows.Next() {
+		var i SearchChirpsRow
+		if err := rows.Scan(
+			&i.Chirp.ID,
+			&i.Chirp.CreatedAt,
+			&i.Chirp.UpdatedAt,
+			&i.Chirp.Body,
+			&i.Chirp.UserID,
+			&i.Chirp.BodyTsv,
+			&i.Chirp.InReplyTo,
+			&i.Chirp.LikeCount,
+			&i.Chirp.ChirpKind,
+			&i.Chirp.RefChirpID,
+			&i.Chirp.Status,
+			&i.Chirp.PublishAt,
+			&i.Chirp.EditedAt,
+			&i.Chirp.DeletedAt,
+			&i.Rank,
+			&i.Headline,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const softDeleteChirp = `-- name: SoftDeleteChirp :execrows
+UPDATE chirps
+SET deleted_at = $1
+WHERE id = $2 AND user_id = $3 AND deleted_at IS NULL
+`
+
+type SoftDeleteChirpParams struct {
+	DeletedAt sql.NullTime
+	ID        uuid.UUID
+	UserID    uuid.UUID
+}
+
+func (q *Queries) SoftDeleteChirp(ctx context.Context, arg SoftDeleteChirpParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, softDeleteChirp, arg.DeletedAt, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const softDeleteRechirpsOf = `-- name: SoftDeleteRechirpsOf :exec
+UPDATE chirps
+SET deleted_at = $1
+WHERE ref_chirp_id = $2 AND chirp_kind = 'rechirp' AND deleted_at IS NULL
+`
+
+type SoftDeleteRechirpsOfParams struct {
+	DeletedAt  sql.NullTime
+	RefChirpID uuid.NullUUID
+}
+
+func (q *Queries) SoftDeleteRechirpsOf(ctx context.Context, arg SoftDeleteRechirpsOfParams) error {
+	_, err := q.db.ExecContext(ctx, softDeleteRechirpsOf, arg.DeletedAt, arg.RefChirpID)
+	return err
+}
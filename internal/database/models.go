@@ -5,17 +5,192 @@
 package database
 
 import (
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+type Block struct {
+	BlockerID uuid.UUID
+	BlockedID uuid.UUID
+	CreatedAt time.Time
+}
+
+type Bookmark struct {
+	UserID    uuid.UUID
+	ChirpID   uuid.UUID
+	CreatedAt time.Time
+}
+
 type Chirp struct {
-	ID        uuid.UUID
+	ID         uuid.UUID
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	Body       string
+	UserID     uuid.UUID
+	BodyTsv    interface{}
+	InReplyTo  uuid.NullUUID
+	LikeCount  int32
+	ChirpKind  string
+	RefChirpID uuid.NullUUID
+	Status     string
+	PublishAt  sql.NullTime
+	EditedAt   sql.NullTime
+	DeletedAt  sql.NullTime
+}
+
+type ChirpLike struct {
+	ChirpID   uuid.UUID
+	UserID    uuid.UUID
+	CreatedAt time.Time
+}
+
+type ChirpMention struct {
+	ChirpID     uuid.UUID
+	UserID      uuid.UUID
+	CreatedAt   time.Time
+	StartOffset int32
+	Length      int32
+}
+
+type ChirpRevision struct {
+	ID         uuid.UUID
+	ChirpID    uuid.UUID
+	Body       string
+	CreatedAt  time.Time
+	ReplacedAt time.Time
+}
+
+type ChirpTag struct {
+	ChirpID   uuid.UUID
+	TagID     uuid.UUID
+	CreatedAt time.Time
+}
+
+type FanoutJob struct {
+	ID         int64
+	Kind       string
+	ChirpID    uuid.NullUUID
+	FollowerID uuid.NullUUID
+	FolloweeID uuid.NullUUID
+	Attempts   int32
+	RunAfter   time.Time
+	CreatedAt  time.Time
+}
+
+type Follow struct {
+	FollowerID uuid.UUID
+	FolloweeID uuid.UUID
+	CreatedAt  time.Time
+}
+
+type HeldChirp struct {
+	ID           uuid.UUID
+	CreatedAt    time.Time
+	Body         string
+	UserID       uuid.UUID
+	MatchedWords []string
+	InReplyTo    uuid.NullUUID
+	RefChirpID   uuid.NullUUID
+}
+
+type HomeTimeline struct {
+	UserID    uuid.UUID
+	ChirpID   uuid.UUID
+	CreatedAt time.Time
+}
+
+type LinkPreview struct {
+	ChirpID     uuid.UUID
+	Url         string
+	Title       string
+	Description string
+	ImageUrl    string
+	SiteName    string
+	FetchedAt   time.Time
+}
+
+type ListMember struct {
+	ListID    uuid.UUID
+	UserID    uuid.UUID
+	CreatedAt time.Time
+}
+
+type MediaAttachment struct {
+	ID          uuid.UUID
+	UserID      uuid.UUID
+	ChirpID     uuid.NullUUID
+	HeldChirpID uuid.NullUUID
+	Position    int32
+	CreatedAt   time.Time
+	ContentType string
+	SizeBytes   int32
+	Width       int32
+	Height      int32
+	BlobKey     string
+	ThumbKey    string
+}
+
+type ModerationWord struct {
+	Word      string
+	Policy    string
+	Allowed   bool
 	CreatedAt time.Time
 	UpdatedAt time.Time
+}
+
+type Mute struct {
+	MuterID   uuid.UUID
+	MutedID   uuid.UUID
+	CreatedAt time.Time
+}
+
+type PolkaEvent struct {
+	ID         string
+	Event      string
+	UserID     uuid.UUID
+	ReceivedAt time.Time
+}
+
+type Poll struct {
+	ID          uuid.UUID
+	ChirpID     uuid.NullUUID
+	HeldChirpID uuid.NullUUID
+	ClosesAt    time.Time
+	CreatedAt   time.Time
+}
+
+type PollOption struct {
+	ID        uuid.UUID
+	PollID    uuid.UUID
+	Position  int32
 	Body      string
+	VoteCount int32
+}
+
+type PollVote struct {
+	PollID    uuid.UUID
 	UserID    uuid.UUID
+	OptionID  uuid.UUID
+	CreatedAt time.Time
+}
+
+type RefreshToken struct {
+	Token      string
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	UserID     uuid.UUID
+	FamilyID   uuid.UUID
+	ExpiresAt  time.Time
+	RevokedAt  sql.NullTime
+	ReplacedBy sql.NullString
+}
+
+type Tag struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	Name      string
 }
 
 type User struct {
@@ -24,4 +199,17 @@ type User struct {
 	UpdatedAt      time.Time
 	Email          string
 	HashedPassword string
+	IsChirpyRed    bool
+	Username       sql.NullString
+	FollowerCount  int32
+	DeletedAt      sql.NullTime
+}
+
+type UserList struct {
+	ID        uuid.UUID
+	OwnerID   uuid.UUID
+	Name      string
+	IsPrivate bool
+	CreatedAt time.Time
+	UpdatedAt time.Time
 }
@@ -7,21 +7,24 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const createUser = `-- name: CreateUser :one
-INSERT INTO users (id, created_at, updated_at, email, hashed_password)
+INSERT INTO users (id, created_at, updated_at, email, hashed_password, username)
 VALUES (
     $1,
     $2,
     $3,
     $4,
-    $5
+    $5,
+    $6
 )
-RETURNING id, created_at, updated_at, email, hashed_password
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, username, follower_count, deleted_at
 `
 
 type CreateUserParams struct {
@@ -30,6 +33,7 @@ type CreateUserParams struct {
 	UpdatedAt      time.Time
 	Email          string
 	HashedPassword string
+	Username       sql.NullString
 }
 
 func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
@@ -39,6 +43,7 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		arg.UpdatedAt,
 		arg.Email,
 		arg.HashedPassword,
+		arg.Username,
 	)
 	var i User
 	err := row.Scan(
@@ -47,12 +52,61 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.UpdatedAt,
 		&i.Email,
 		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.Username,
+		&i.FollowerCount,
+		&i.DeletedAt,
 	)
 	return i, err
 }
 
+const getMentionableUsers = `-- name: GetMentionableUsers :many
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, username, follower_count, deleted_at FROM users
+WHERE LOWER(username) = ANY($1::TEXT[])
+AND deleted_at IS NULL
+AND NOT is_blocked_between(users.id, $2)
+`
+
+type GetMentionableUsersParams struct {
+	Usernames []string
+	AuthorID  uuid.UUID
+}
+
+func (q *Queries) GetMentionableUsers(ctx context.Context, arg GetMentionableUsersParams) ([]User, error) {
+	rows, err := q.db.QueryContext(ctx, getMentionableUsers, pq.Array(arg.Usernames), arg.AuthorID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Email,
+			&i.HashedPassword,
+			&i.IsChirpyRed,
+			&i.Username,
+			&i.FollowerCount,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getUserFromEmail = `-- name: GetUserFromEmail :one
-SELECT id, created_at, updated_at, email, hashed_password FROM users WHERE email = $1 LIMIT 1
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, username, follower_count, deleted_at FROM users WHERE email = $1 LIMIT 1
 `
 
 func (q *Queries) GetUserFromEmail(ctx context.Context, email string) (User, error) {
@@ -64,6 +118,60 @@ func (q *Queries) GetUserFromEmail(ctx context.Context, email string) (User, err
 		&i.UpdatedAt,
 		&i.Email,
 		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.Username,
+		&i.FollowerCount,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const getUserFromId = `-- name: GetUserFromId :one
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, username, follower_count, deleted_at FROM users WHERE id = $1 AND deleted_at IS NULL LIMIT 1
+`
+
+func (q *Queries) GetUserFromId(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserFromId, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.Username,
+		&i.FollowerCount,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const getVisibleUser = `-- name: GetVisibleUser :one
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, username, follower_count, deleted_at FROM users
+WHERE id = $1 AND deleted_at IS NULL
+AND NOT is_blocked_between($2::UUID, users.id)
+LIMIT 1
+`
+
+type GetVisibleUserParams struct {
+	ID       uuid.UUID
+	ViewerID uuid.NullUUID
+}
+
+func (q *Queries) GetVisibleUser(ctx context.Context, arg GetVisibleUserParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, getVisibleUser, arg.ID, arg.ViewerID)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.Username,
+		&i.FollowerCount,
+		&i.DeletedAt,
 	)
 	return i, err
 }
@@ -76,3 +184,157 @@ func (q *Queries) ResetUsers(ctx context.Context) error {
 	_, err := q.db.ExecContext(ctx, resetUsers)
 	return err
 }
+
+const restoreUser = `-- name: RestoreUser :one
+UPDATE users
+SET deleted_at = NULL
+WHERE id = $1 AND deleted_at >= $2
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, username, follower_count, deleted_at
+`
+
+type RestoreUserParams struct {
+	ID           uuid.UUID
+	DeletedSince sql.NullTime
+}
+
+func (q *Queries) RestoreUser(ctx context.Context, arg RestoreUserParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, restoreUser, arg.ID, arg.DeletedSince)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.Username,
+		&i.FollowerCount,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const restoreUserChirps = `-- name: RestoreUserChirps :exec
+UPDATE chirps
+SET deleted_at = NULL
+WHERE deleted_at = $1
+AND (user_id = $2
+    OR (chirp_kind = 'rechirp' AND ref_chirp_id IN (SELECT id FROM chirps authored WHERE authored.user_id = $2)))
+`
+
+type RestoreUserChirpsParams struct {
+	DeletedAt sql.NullTime
+	UserID    uuid.UUID
+}
+
+func (q *Queries) RestoreUserChirps(ctx context.Context, arg RestoreUserChirpsParams) error {
+	_, err := q.db.ExecContext(ctx, restoreUserChirps, arg.DeletedAt, arg.UserID)
+	return err
+}
+
+const softDeleteUser = `-- name: SoftDeleteUser :execrows
+UPDATE users
+SET deleted_at = $1
+WHERE id = $2 AND deleted_at IS NULL
+`
+
+type SoftDeleteUserParams struct {
+	DeletedAt sql.NullTime
+	ID        uuid.UUID
+}
+
+func (q *Queries) SoftDeleteUser(ctx context.Context, arg SoftDeleteUserParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, softDeleteUser, arg.DeletedAt, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const softDeleteUserChirps = `-- name: SoftDeleteUserChirps :exec
+UPDATE chirps
+SET deleted_at = $1
+WHERE deleted_at IS NULL
+AND (user_id = $2
+    OR (chirp_kind = 'rechirp' AND ref_chirp_id IN (SELECT id FROM chirps authored WHERE authored.user_id = $2)))
+`
+
+type SoftDeleteUserChirpsParams struct {
+	DeletedAt sql.NullTime
+	UserID    uuid.UUID
+}
+
+func (q *Queries) SoftDeleteUserChirps(ctx context.Context, arg SoftDeleteUserChirpsParams) error {
+	_, err := q.db.ExecContext(ctx, softDeleteUserChirps, arg.DeletedAt, arg.UserID)
+	return err
+}
+
+const updateUser = `-- name: UpdateUser :one
+UPDATE users
+SET email = $2, hashed_password = $3, username = $4, updated_at = $5
+WHERE id = $1 AND deleted_at IS NULL
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, username, follower_count, deleted_at
+`
+
+type UpdateUserParams struct {
+	ID             uuid.UUID
+	Email          string
+	HashedPassword string
+	Username       sql.NullString
+	UpdatedAt      time.Time
+}
+
+func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUser,
+		arg.ID,
+		arg.Email,
+		arg.HashedPassword,
+		arg.Username,
+		arg.UpdatedAt,
+	)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.Username,
+		&i.FollowerCount,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const upgradeUserToChirpyRed = `-- name: UpgradeUserToChirpyRed :execrows
+UPDATE users
+SET is_chirpy_red = TRUE, updated_at = $2
+WHERE id = $1 AND deleted_at IS NULL
+`
+
+type UpgradeUserToChirpyRedParams struct {
+	ID        uuid.UUID
+	UpdatedAt time.Time
+}
+
+func (q *Queries) UpgradeUserToChirpyRed(ctx context.Context, arg UpgradeUserToChirpyRedParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, upgradeUserToChirpyRed, arg.ID, arg.UpdatedAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const userIsLive = `-- name: UserIsLive :one
+SELECT EXISTS (
+    SELECT 1 FROM users WHERE id = $1 AND deleted_at IS NULL
+)
+`
+
+func (q *Queries) UserIsLive(ctx context.Context, id uuid.UUID) (bool, error) {
+	row := q.db.QueryRowContext(ctx, userIsLive, id)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
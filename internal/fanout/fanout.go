@@ -0,0 +1,225 @@
+// Package fanout materialises home timelines. New chirps are written into a
+// row per follower as they are posted (fan-out on write), except for authors
+// with more followers than the configured limit, whose chirps are merged in
+// when the timeline is read instead (fan-out on read).
+//
+// Timeline changes are queued as rows in fanout_jobs, written in the same
+// transaction as the chirp or follow that causes them, so none are lost to a
+// restart or a failed write. Every server can run a worker: jobs are claimed
+// with FOR UPDATE SKIP LOCKED, and a job that fails stays queued and is tried
+// again later.
+package fanout
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shahanmmiah/Chirpy/internal/database"
+)
+
+// DefaultLimit is the follower count above which an author's chirps are no
+// longer copied into each follower's timeline.
+const DefaultLimit = 10000
+
+// BackfillLimit is how many of a user's recent chirps are copied into a new
+// follower's timeline.
+const BackfillLimit = 200
+
+// DefaultInterval is how often the queue is checked when no worker has been
+// woken, which covers jobs queued by other servers and retries.
+const DefaultInterval = 5 * time.Second
+
+// MaxRetryDelay caps the backoff between attempts at a failing job.
+const MaxRetryDelay = time.Hour
+
+const (
+	jobChirp    = "chirp"
+	jobFollow   = "follow"
+	jobUnfollow = "unfollow"
+	jobAuthor   = "author"
+)
+
+// Worker applies queued timeline changes in the background. Jobs are claimed
+// oldest first, but a follow and a later unfollow may run on different
+// servers, so both check the current follow before changing anything.
+type Worker struct {
+	db       *sql.DB
+	queries  *database.Queries
+	limit    int32
+	interval time.Duration
+	wake     chan struct{}
+}
+
+func NewWorker(db *sql.DB, queries *database.Queries, limit int32, interval time.Duration) *Worker {
+	return &Worker{
+		db:       db,
+		queries:  queries,
+		limit:    limit,
+		interval: interval,
+		wake:     make(chan struct{}, 1),
+	}
+}
+
+// Limit is the follower count above which chirps are read at query time.
+func (w *Worker) Limit() int32 {
+	return w.limit
+}
+
+// Chirp queues a newly published chirp for delivery to its author's
+// followers. queries should belong to the transaction that publishes it.
+func (w *Worker) Chirp(ctx context.Context, queries *database.Queries, chirpID uuid.UUID) error {
+	return w.enqueue(ctx, queries, jobChirp, uuid.NullUUID{UUID: chirpID, Valid: true}, uuid.NullUUID{}, uuid.NullUUID{})
+}
+
+// Follow queues a backfill of followee's recent chirps for follower.
+func (w *Worker) Follow(ctx context.Context, queries *database.Queries, follower, followee uuid.UUID) error {
+	return w.enqueue(ctx, queries, jobFollow, uuid.NullUUID{}, uuid.NullUUID{UUID: follower, Valid: true}, uuid.NullUUID{UUID: followee, Valid: true})
+}
+
+// Unfollow queues removal of followee's chirps from follower's timeline.
+func (w *Worker) Unfollow(ctx context.Context, queries *database.Queries, follower, followee uuid.UUID) error {
+	return w.enqueue(ctx, queries, jobUnfollow, uuid.NullUUID{}, uuid.NullUUID{UUID: follower, Valid: true}, uuid.NullUUID{UUID: followee, Valid: true})
+}
+
+// FollowerCountChanged is told a user's follower count after it moved by
+// delta. Chirps posted while the user was over the limit were never copied
+// into timelines, so dropping back under it queues a backfill for every
+// follower; otherwise they would vanish from home timelines.
+func (w *Worker) FollowerCountChanged(ctx context.Context, queries *database.Queries, userID uuid.UUID, count, delta int32) error {
+	if !w.droppedBelowLimit(count, delta) {
+		return nil
+	}
+	return w.enqueue(ctx, queries, jobAuthor, uuid.NullUUID{}, uuid.NullUUID{}, uuid.NullUUID{UUID: userID, Valid: true})
+}
+
+// droppedBelowLimit reports whether a follower count that moved by delta to
+// count went from over the limit to at or under it.
+func (w *Worker) droppedBelowLimit(count, delta int32) bool {
+	return count <= w.limit && count-delta > w.limit
+}
+
+func (w *Worker) enqueue(ctx context.Context, queries *database.Queries, kind string, chirpID, follower, followee uuid.NullUUID) error {
+	return queries.EnqueueFanoutJob(ctx, database.EnqueueFanoutJobParams{
+		Kind:       kind,
+		ChirpID:    chirpID,
+		FollowerID: follower,
+		FolloweeID: followee,
+		CreatedAt:  time.Now()})
+}
+
+// Wake tells the worker new jobs have been committed. It never blocks: if a
+// wake-up is already pending this one is dropped, and a worker that misses it
+// still finds the jobs on its next poll.
+func (w *Worker) Wake() {
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run works through due jobs whenever it is woken and every interval, until
+// ctx is cancelled.
+func (w *Worker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			ran, err := w.RunNext(ctx, time.Now())
+			if err != nil {
+				log.Printf("fanout: %v", err)
+			}
+			if !ran {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-w.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunNext claims the oldest job due at now and applies it in the same
+// transaction that removes it from the queue. It reports whether there was a
+// job to run. A job that fails is pushed back with a growing delay.
+func (w *Worker) RunNext(ctx context.Context, now time.Time) (bool, error) {
+	tx, err := w.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	queries := w.queries.WithTx(tx)
+
+	j, err := queries.ClaimFanoutJob(ctx, now)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	err = w.handle(ctx, queries, j)
+	if err == nil {
+		err = queries.DeleteFanoutJob(ctx, j.ID)
+	}
+	if err == nil {
+		return true, tx.Commit()
+	}
+
+	tx.Rollback()
+	retryErr := w.queries.RetryFanoutJob(ctx, database.RetryFanoutJobParams{
+		RunAfter: now.Add(RetryDelay(j.Attempts)),
+		ID:       j.ID})
+
+	return true, errors.Join(err, retryErr)
+}
+
+// RetryDelay is how long to wait before the next attempt at a job that has
+// already failed attempts times: doubling from a second, up to MaxRetryDelay.
+func RetryDelay(attempts int32) time.Duration {
+	if attempts >= 12 {
+		return MaxRetryDelay
+	}
+	return min(time.Second<<attempts, MaxRetryDelay)
+}
+
+func (w *Worker) handle(ctx context.Context, queries *database.Queries, j database.FanoutJob) error {
+	switch j.Kind {
+	case jobChirp:
+		_, err := queries.FanOutChirp(ctx, database.FanOutChirpParams{
+			ChirpID:     j.ChirpID.UUID,
+			FanoutLimit: w.limit})
+		return err
+
+	case jobFollow:
+		_, err := queries.BackfillHomeTimeline(ctx, database.BackfillHomeTimelineParams{
+			UserID:      j.FollowerID.UUID,
+			FolloweeID:  j.FolloweeID.UUID,
+			FanoutLimit: w.limit,
+			RowLimit:    BackfillLimit})
+		return err
+
+	case jobUnfollow:
+		_, err := queries.RemoveFolloweeFromHomeTimeline(ctx, database.RemoveFolloweeFromHomeTimelineParams{
+			UserID:     j.FollowerID.UUID,
+			FolloweeID: j.FolloweeID.UUID})
+		return err
+
+	case jobAuthor:
+		_, err := queries.BackfillFollowerTimelines(ctx, database.BackfillFollowerTimelinesParams{
+			AuthorID:    j.FolloweeID.UUID,
+			FanoutLimit: w.limit,
+			RowLimit:    BackfillLimit})
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,333 @@
+package fanout
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	_ "github.com/lib/pq"
+	"github.com/shahanmmiah/Chirpy/internal/database"
+)
+
+const benchFollowees = 500
+const benchChirpsEach = 40
+const benchPageSize = 51
+
+// seedTimeline creates a reader following benchFollowees users who have each
+// posted benchChirpsEach chirps, and materialises the reader's timeline. It
+// needs a migrated database in CHIRPY_BENCH_DB_URL.
+func seedTimeline(b *testing.B) (*database.Queries, uuid.UUID) {
+	dbURL := os.Getenv("CHIRPY_BENCH_DB_URL")
+	if dbURL == "" {
+		b.Skip("CHIRPY_BENCH_DB_URL not set")
+	}
+
+	db, err := sql.Open("postgres", dbURL)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { db.Close() })
+
+	ctx := context.Background()
+	prefix := "fanout-bench-" + uuid.NewString() + "-"
+	reader := uuid.New()
+
+	b.Cleanup(func() {
+		db.ExecContext(ctx, `DELETE FROM users WHERE email LIKE $1 || '%'`, prefix)
+	})
+
+	seed := []struct {
+		query string
+		args  []any
+	}{
+		{`INSERT INTO users(id, created_at, updated_at, email, hashed_password)
+			VALUES ($1, NOW(), NOW(), $2 || 'reader', 'unset')`, []any{reader, prefix}},
+		{`INSERT INTO users(id, created_at, updated_at, email, hashed_password, follower_count)
+			SELECT gen_random_uuid(), NOW(), NOW(), $1 || n, 'unset', 1 FROM generate_series(1, $2::INT) n`, []any{prefix, benchFollowees}},
+		{`INSERT INTO follows(follower_id, followee_id, created_at)
+			SELECT $1, id, NOW() FROM users WHERE email LIKE $2 || '%' AND id <> $1`, []any{reader, prefix}},
+		{`INSERT INTO chirps(id, created_at, updated_at, body, user_id)
+			SELECT gen_random_uuid(), NOW() - n * INTERVAL '1 minute' - random() * INTERVAL '1 minute', NOW(), 'bench chirp', users.id
+			FROM users, generate_series(1, $2::INT) n WHERE users.email LIKE $1 || '%' AND users.id <> $3`, []any{prefix, benchChirpsEach, reader}},
+	}
+	for _, s := range seed {
+		if _, err := db.ExecContext(ctx, s.query, s.args...); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	queries := database.New(db)
+	w := NewWorker(db, queries, DefaultLimit, DefaultInterval)
+
+	rows, err := db.QueryContext(ctx, `SELECT followee_id FROM follows WHERE follower_id = $1`, reader)
+	if err != nil {
+		b.Fatal(err)
+	}
+	followees := []uuid.UUID{}
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			b.Fatal(err)
+		}
+		followees = append(followees, id)
+	}
+	rows.Close()
+
+	for _, followee := range followees {
+		j := database.FanoutJob{
+			Kind:       jobFollow,
+			FollowerID: uuid.NullUUID{UUID: reader, Valid: true},
+			FolloweeID: uuid.NullUUID{UUID: followee, Valid: true}}
+
+		if err := w.handle(ctx, queries, j); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	return queries, reader
+}
+
+// BenchmarkHomeTimeline compares reading the first page of a home timeline by
+// joining follows against chirps with reading the materialised rows.
+func BenchmarkHomeTimeline(b *testing.B) {
+	queries, reader := seedTimeline(b)
+	ctx := context.Background()
+
+	b.Run("fan-out-on-read", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_, err := queries.ListHomeTimeline(ctx, database.ListHomeTimelineParams{
+				UserID:   reader,
+				RowLimit: benchPageSize})
+
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("fan-out-on-write", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_, err := queries.ListMaterialisedHomeTimeline(ctx, database.ListMaterialisedHomeTimelineParams{
+				UserID:      reader,
+				RowLimit:    benchPageSize,
+				FanoutLimit: DefaultLimit})
+
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestRetryDelay(t *testing.T) {
+	cases := []struct {
+		InputAttempts int32
+		ExpectedDelay time.Duration
+	}{
+		{InputAttempts: 0, ExpectedDelay: time.Second},
+		{InputAttempts: 1, ExpectedDelay: 2 * time.Second},
+		{InputAttempts: 5, ExpectedDelay: 32 * time.Second},
+		{InputAttempts: 11, ExpectedDelay: 2048 * time.Second},
+		{InputAttempts: 12, ExpectedDelay: MaxRetryDelay},
+		{InputAttempts: 400, ExpectedDelay: MaxRetryDelay},
+	}
+	for _, c := range cases {
+		actual := RetryDelay(c.InputAttempts)
+		if actual != c.ExpectedDelay {
+			t.Errorf("RetryDelay(%d) = %v, expected %v", c.InputAttempts, actual, c.ExpectedDelay)
+		}
+	}
+}
+
+func TestDroppedBelowLimit(t *testing.T) {
+	w := NewWorker(nil, nil, DefaultLimit, DefaultInterval)
+
+	cases := []struct {
+		InputCount int32
+		InputDelta int32
+		Expected   bool
+	}{
+		{InputCount: 50, InputDelta: -1, Expected: false},
+		{InputCount: DefaultLimit - 1, InputDelta: -1, Expected: false},
+		{InputCount: DefaultLimit, InputDelta: 1, Expected: false},
+		{InputCount: DefaultLimit + 1, InputDelta: -1, Expected: false},
+		{InputCount: DefaultLimit + 2, InputDelta: 1, Expected: false},
+		// crossing the limit upwards never backfills
+		{InputCount: DefaultLimit + 1, InputDelta: 1, Expected: false},
+		{InputCount: DefaultLimit + 5, InputDelta: 10, Expected: false},
+		// crossing it downwards does, landing on or under it
+		{InputCount: DefaultLimit, InputDelta: -1, Expected: true},
+		{InputCount: DefaultLimit - 5, InputDelta: -10, Expected: true},
+	}
+	for _, c := range cases {
+		actual := w.droppedBelowLimit(c.InputCount, c.InputDelta)
+		if actual != c.Expected {
+			t.Errorf("droppedBelowLimit(%d, %d) = %v, expected %v", c.InputCount, c.InputDelta, actual, c.Expected)
+		}
+	}
+}
+
+func TestFollowerCountChangedOnlyOnDropBelowLimit(t *testing.T) {
+	// only the crossing case touches the database, so nil queries must not
+	// be reached by any of these
+	w := NewWorker(nil, nil, DefaultLimit, DefaultInterval)
+	ctx := context.Background()
+
+	cases := []struct {
+		InputCount int32
+		InputDelta int32
+	}{
+		{InputCount: 50, InputDelta: -1},
+		{InputCount: DefaultLimit, InputDelta: 1},
+		{InputCount: DefaultLimit + 1, InputDelta: 1},
+		{InputCount: DefaultLimit + 1, InputDelta: -1},
+		{InputCount: DefaultLimit - 1, InputDelta: -1},
+	}
+	for _, c := range cases {
+		if err := w.FollowerCountChanged(ctx, nil, uuid.New(), c.InputCount, c.InputDelta); err != nil {
+			t.Errorf("FollowerCountChanged(%d, %d): %v", c.InputCount, c.InputDelta, err)
+		}
+	}
+}
+
+// TestFollowerCountChangedQueuesBackfill moves a user's follower count across
+// the limit both ways and checks only the drop queues an author job. It needs
+// a migrated database in CHIRPY_TEST_DB_URL.
+func TestFollowerCountChangedQueuesBackfill(t *testing.T) {
+	dbURL := os.Getenv("CHIRPY_TEST_DB_URL")
+	if dbURL == "" {
+		t.Skip("CHIRPY_TEST_DB_URL not set")
+	}
+
+	db, err := sql.Open("postgres", dbURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	ctx := context.Background()
+	queries := database.New(db)
+	w := NewWorker(db, queries, DefaultLimit, DefaultInterval)
+
+	cases := []struct {
+		InputCount int32
+		InputDelta int32
+		Expected   int
+	}{
+		{InputCount: DefaultLimit + 1, InputDelta: 1, Expected: 0},
+		{InputCount: DefaultLimit, InputDelta: -1, Expected: 1},
+	}
+	for _, c := range cases {
+		user := uuid.New()
+		t.Cleanup(func() {
+			db.ExecContext(ctx, `DELETE FROM fanout_jobs WHERE followee_id = $1`, user)
+		})
+
+		if err := w.FollowerCountChanged(ctx, queries, user, c.InputCount, c.InputDelta); err != nil {
+			t.Fatal(err)
+		}
+
+		var actual int
+		err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM fanout_jobs WHERE kind = $1 AND followee_id = $2`, jobAuthor, user).Scan(&actual)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual != c.Expected {
+			t.Errorf("FollowerCountChanged(%d, %d) queued %d author jobs, expected %d", c.InputCount, c.InputDelta, actual, c.Expected)
+		}
+	}
+}
+
+// TestMaterialisedHomeTimelineSkipsHidden fills a whole page of both the
+// materialised and the pulled rows with chirps from a muted and a blocking
+// author, and with chirps soft-deleted after they were fanned out, and checks
+// the older visible chirps behind them are still listed.
+// It needs a migrated database in CHIRPY_TEST_DB_URL.
+func TestMaterialisedHomeTimelineSkipsHidden(t *testing.T) {
+	dbURL := os.Getenv("CHIRPY_TEST_DB_URL")
+	if dbURL == "" {
+		t.Skip("CHIRPY_TEST_DB_URL not set")
+	}
+
+	db, err := sql.Open("postgres", dbURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	ctx := context.Background()
+	prefix := "fanout-test-" + uuid.NewString() + "-"
+	reader, muted, blocking, deleter, kept := uuid.New(), uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	const pageSize = 3
+	const limit = 2
+
+	t.Cleanup(func() {
+		db.ExecContext(ctx, `DELETE FROM users WHERE email LIKE $1 || '%'`, prefix)
+	})
+
+	seed := []struct {
+		query string
+		args  []any
+	}{
+		{`INSERT INTO users(id, created_at, updated_at, email, hashed_password, follower_count)
+			VALUES ($1, NOW(), NOW(), $6 || 'reader', 'unset', 0),
+			($2, NOW(), NOW(), $6 || 'muted', 'unset', 1),
+			($3, NOW(), NOW(), $6 || 'blocking', 'unset', $7::INT + 1),
+			($4, NOW(), NOW(), $6 || 'deleter', 'unset', 1),
+			($5, NOW(), NOW(), $6 || 'kept', 'unset', 1)`, []any{reader, muted, blocking, deleter, kept, prefix, limit}},
+		{`INSERT INTO follows(follower_id, followee_id, created_at)
+			VALUES ($1, $2, NOW()), ($1, $3, NOW()), ($1, $4, NOW()), ($1, $5, NOW())`, []any{reader, muted, blocking, deleter, kept}},
+		{`INSERT INTO mutes(muter_id, muted_id, created_at) VALUES ($1, $2, NOW())`, []any{reader, muted}},
+		{`INSERT INTO blocks(blocker_id, blocked_id, created_at) VALUES ($1, $2, NOW())`, []any{blocking, reader}},
+
+		// the hidden authors' chirps are newer than a page's worth of the
+		// kept author's
+		{`INSERT INTO chirps(id, created_at, updated_at, body, user_id)
+			SELECT gen_random_uuid(), NOW() - n * INTERVAL '1 minute', NOW(), 'hidden', author
+			FROM generate_series(1, $4::INT) n, unnest(ARRAY[$1, $2, $3]::UUID[]) author`, []any{muted, blocking, deleter, pageSize}},
+		{`INSERT INTO chirps(id, created_at, updated_at, body, user_id)
+			SELECT gen_random_uuid(), NOW() - INTERVAL '1 hour' - n * INTERVAL '1 minute', NOW(), 'kept', $1
+			FROM generate_series(1, $2::INT) n`, []any{kept, pageSize}},
+	}
+	for _, s := range seed {
+		if _, err := db.ExecContext(ctx, s.query, s.args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	queries := database.New(db)
+	w := NewWorker(db, queries, limit, DefaultInterval)
+	for _, followee := range []uuid.UUID{muted, deleter, kept} {
+		j := database.FanoutJob{
+			Kind:       jobFollow,
+			FollowerID: uuid.NullUUID{UUID: reader, Valid: true},
+			FolloweeID: uuid.NullUUID{UUID: followee, Valid: true}}
+
+		if err := w.handle(ctx, queries, j); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := db.ExecContext(ctx, `UPDATE chirps SET deleted_at = NOW() WHERE user_id = $1`, deleter); err != nil {
+		t.Fatal(err)
+	}
+
+	chirps, err := queries.ListMaterialisedHomeTimeline(ctx, database.ListMaterialisedHomeTimelineParams{
+		UserID:      reader,
+		RowLimit:    pageSize,
+		FanoutLimit: limit})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chirps) != pageSize {
+		t.Errorf("listed %d chirps, expected %d", len(chirps), pageSize)
+	}
+	for _, c := range chirps {
+		if c.UserID != kept {
+			t.Errorf("listed chirp %v by hidden author %v", c.ID, c.UserID)
+		}
+	}
+}
@@ -0,0 +1,256 @@
+// Package media validates uploaded images, strips their metadata, makes
+// thumbnails and stores the results behind a BlobStore.
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+)
+
+// MaxBytes is the default upload size limit.
+const MaxBytes = 5 << 20
+
+// MaxPixels bounds the decoded size of an image, so a small file cannot
+// claim dimensions that would exhaust memory once decoded.
+const MaxPixels = 40_000_000
+
+// MaxFrames bounds the number of frames in an animated GIF. The frames
+// together are also held to MaxPixels, since every one is decoded at once.
+const MaxFrames = 500
+
+// ThumbSize is the longest side of a thumbnail in pixels.
+const ThumbSize = 320
+
+const JPEGQuality = 90
+
+var ErrUnsupportedType = errors.New("unsupported media type, expected PNG, JPEG or GIF")
+
+// extensions maps each accepted content type to the extension blobs of that
+// type are stored with.
+var extensions = map[string]string{
+	"image/png":  ".png",
+	"image/jpeg": ".jpg",
+	"image/gif":  ".gif",
+}
+
+// Allowed reports whether uploads declared as contentType are accepted.
+func Allowed(contentType string) bool {
+	_, ok := extensions[contentType]
+	return ok
+}
+
+// Image is an upload that has been decoded and encoded again. The standard
+// library encoders only write pixel data, so re-encoding is what drops EXIF,
+// XMP and comment blocks from the original. JPEG orientation is applied to
+// the pixels first so photos still display the right way up.
+type Image struct {
+	ContentType string
+	Ext         string
+	Data        []byte
+	Width       int
+	Height      int
+
+	// Thumb is always a still image; GIF thumbnails are PNGs of the first
+	// frame.
+	Thumb    []byte
+	ThumbExt string
+}
+
+// Process sniffs the real type of data rather than trusting the client, then
+// re-encodes it and renders a thumbnail.
+func Process(data []byte) (Image, error) {
+	contentType := http.DetectContentType(data)
+	ext, ok := extensions[contentType]
+	if !ok {
+		return Image{}, ErrUnsupportedType
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return Image{}, fmt.Errorf("invalid image: %v", err)
+	}
+	if cfg.Width*cfg.Height > MaxPixels {
+		return Image{}, fmt.Errorf("image is %dx%d, at most %d pixels are allowed", cfg.Width, cfg.Height, MaxPixels)
+	}
+
+	img := Image{ContentType: contentType, Ext: ext}
+	var out bytes.Buffer
+	var still image.Image
+
+	switch contentType {
+	case "image/jpeg":
+		decoded, err := jpeg.Decode(bytes.NewReader(data))
+		if err != nil {
+			return Image{}, fmt.Errorf("invalid image: %v", err)
+		}
+		still = orient(decoded, jpegOrientation(data))
+		err = jpeg.Encode(&out, still, &jpeg.Options{Quality: JPEGQuality})
+		if err != nil {
+			return Image{}, err
+		}
+
+	case "image/png":
+		decoded, err := png.Decode(bytes.NewReader(data))
+		if err != nil {
+			return Image{}, fmt.Errorf("invalid image: %v", err)
+		}
+		still = decoded
+		err = png.Encode(&out, still)
+		if err != nil {
+			return Image{}, err
+		}
+
+	case "image/gif":
+		// count the frames before decoding any of them: DecodeAll holds
+		// every frame in memory, so the header check alone is not enough
+		if err := checkGIFFrames(data); err != nil {
+			return Image{}, err
+		}
+		decoded, err := gif.DecodeAll(bytes.NewReader(data))
+		if err != nil {
+			return Image{}, fmt.Errorf("invalid image: %v", err)
+		}
+		// the first frame may only cover part of the logical screen
+		first := decoded.Image[0]
+		canvas := image.NewRGBA(image.Rect(0, 0, cfg.Width, cfg.Height))
+		draw.Draw(canvas, first.Bounds(), first, first.Bounds().Min, draw.Over)
+		still = canvas
+		err = gif.EncodeAll(&out, decoded)
+		if err != nil {
+			return Image{}, err
+		}
+	}
+
+	img.Data = out.Bytes()
+	img.Width = still.Bounds().Dx()
+	img.Height = still.Bounds().Dy()
+
+	var thumb bytes.Buffer
+	if contentType == "image/jpeg" {
+		img.ThumbExt = ".jpg"
+		err = jpeg.Encode(&thumb, Thumbnail(still, ThumbSize), &jpeg.Options{Quality: JPEGQuality})
+	} else {
+		img.ThumbExt = ".png"
+		err = png.Encode(&thumb, Thumbnail(still, ThumbSize))
+	}
+	if err != nil {
+		return Image{}, err
+	}
+	img.Thumb = thumb.Bytes()
+
+	return img, nil
+}
+
+// checkGIFFrames walks the blocks of a GIF without decoding any pixel data,
+// failing if it has more than MaxFrames frames or they add up to more than
+// MaxPixels.
+func checkGIFFrames(data []byte) error {
+	errTruncated := errors.New("invalid image: gif is truncated")
+
+	// header and logical screen descriptor, then the global colour table
+	if len(data) < 13 {
+		return errTruncated
+	}
+	p := 13
+	if data[10]&0x80 != 0 {
+		p += 3 << (data[10]&0x07 + 1)
+	}
+
+	frames, pixels := 0, 0
+	for {
+		if p >= len(data) {
+			return errTruncated
+		}
+
+		switch data[p] {
+		case 0x21: // extension: introducer and label, then sub-blocks
+			p += 2
+
+		case 0x2c: // image descriptor, local colour table, LZW code size
+			if p+10 > len(data) {
+				return errTruncated
+			}
+			w := int(binary.LittleEndian.Uint16(data[p+5:]))
+			h := int(binary.LittleEndian.Uint16(data[p+7:]))
+			flags := data[p+9]
+			p += 10
+			if flags&0x80 != 0 {
+				p += 3 << (flags&0x07 + 1)
+			}
+			p++
+
+			frames++
+			pixels += w * h
+			if frames > MaxFrames {
+				return fmt.Errorf("gif has more than %d frames", MaxFrames)
+			}
+			if pixels > MaxPixels {
+				return fmt.Errorf("gif frames add up to more than %d pixels", MaxPixels)
+			}
+
+		case 0x3b: // trailer
+			return nil
+
+		default:
+			return fmt.Errorf("invalid image: unknown gif block %#x", data[p])
+		}
+
+		// data sub-blocks, up to the empty one that ends them
+		for {
+			if p >= len(data) {
+				return errTruncated
+			}
+			n := int(data[p])
+			p += n + 1
+			if n == 0 {
+				break
+			}
+		}
+	}
+}
+
+// Thumbnail scales src down to fit in a size x size box, keeping its aspect
+// ratio. Each output pixel is the average of the source pixels it covers.
+// Images that already fit are returned as they are.
+func Thumbnail(src image.Image, size int) image.Image {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= size && h <= size {
+		return src
+	}
+
+	tw, th := size, size
+	if w > h {
+		th = max(1, h*size/w)
+	} else {
+		tw = max(1, w*size/h)
+	}
+
+	dst := image.NewRGBA64(image.Rect(0, 0, tw, th))
+	for y := 0; y < th; y++ {
+		y0, y1 := b.Min.Y+y*h/th, b.Min.Y+(y+1)*h/th
+		for x := 0; x < tw; x++ {
+			x0, x1 := b.Min.X+x*w/tw, b.Min.X+(x+1)*w/tw
+
+			var r, g, bl, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := src.At(sx, sy).RGBA()
+					r, g, bl, a = r+uint64(cr), g+uint64(cg), bl+uint64(cb), a+uint64(ca)
+					n++
+				}
+			}
+			dst.SetRGBA64(x, y, color.RGBA64{R: uint16(r / n), G: uint16(g / n), B: uint16(bl / n), A: uint16(a / n)})
+		}
+	}
+	return dst
+}
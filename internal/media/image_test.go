@@ -0,0 +1,213 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func testImage(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 200, A: 255})
+		}
+	}
+	return img
+}
+
+// withExif splices an APP1 segment holding only an orientation tag in after
+// the JPEG's start-of-image marker.
+func withExif(t *testing.T, jpg []byte, orientation uint16) []byte {
+	t.Helper()
+
+	tiff := []byte("MM\x00\x2a\x00\x00\x00\x08")
+	tiff = binary.BigEndian.AppendUint16(tiff, 1)
+	tiff = binary.BigEndian.AppendUint16(tiff, exifOrientationTag)
+	tiff = binary.BigEndian.AppendUint16(tiff, 3)
+	tiff = binary.BigEndian.AppendUint32(tiff, 1)
+	tiff = binary.BigEndian.AppendUint16(tiff, orientation)
+	tiff = append(tiff, 0, 0, 0, 0, 0, 0)
+
+	seg := append([]byte("Exif\x00\x00"), tiff...)
+	app1 := []byte{0xFF, 0xE1}
+	app1 = binary.BigEndian.AppendUint16(app1, uint16(len(seg)+2))
+	app1 = append(app1, seg...)
+
+	out := append([]byte{}, jpg[:2]...)
+	out = append(out, app1...)
+	return append(out, jpg[2:]...)
+}
+
+func TestProcessJPEGStripsExifAndAppliesOrientation(t *testing.T) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, testImage(40, 20), nil); err != nil {
+		t.Fatal(err)
+	}
+	input := withExif(t, buf.Bytes(), 6)
+
+	if got := jpegOrientation(input); got != 6 {
+		t.Fatalf("jpegOrientation = %d, expected 6", got)
+	}
+
+	img, err := Process(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.ContentType != "image/jpeg" || img.Ext != ".jpg" {
+		t.Errorf("Process type = %q %q, expected image/jpeg .jpg", img.ContentType, img.Ext)
+	}
+	if bytes.Contains(img.Data, []byte("Exif")) {
+		t.Errorf("Process output still contains EXIF data")
+	}
+	if img.Width != 20 || img.Height != 40 {
+		t.Errorf("Process size = %dx%d, expected 20x40 after rotating", img.Width, img.Height)
+	}
+}
+
+func TestProcessMakesThumbnails(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, testImage(1000, 500)); err != nil {
+		t.Fatal(err)
+	}
+
+	img, err := Process(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.Width != 1000 || img.Height != 500 {
+		t.Errorf("Process size = %dx%d, expected 1000x500", img.Width, img.Height)
+	}
+
+	thumb, err := png.Decode(bytes.NewReader(img.Thumb))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := thumb.Bounds(); b.Dx() != ThumbSize || b.Dy() != ThumbSize/2 {
+		t.Errorf("thumbnail size = %dx%d, expected %dx%d", b.Dx(), b.Dy(), ThumbSize, ThumbSize/2)
+	}
+}
+
+func TestProcessKeepsGIFFrames(t *testing.T) {
+	palette := color.Palette{color.Black, color.White}
+	anim := &gif.GIF{}
+	for i := 0; i < 3; i++ {
+		anim.Image = append(anim.Image, image.NewPaletted(image.Rect(0, 0, 10, 10), palette))
+		anim.Delay = append(anim.Delay, 10)
+	}
+
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, anim); err != nil {
+		t.Fatal(err)
+	}
+
+	img, err := Process(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := gif.DecodeAll(bytes.NewReader(img.Data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out.Image) != 3 {
+		t.Errorf("Process kept %d frames, expected 3", len(out.Image))
+	}
+	if img.ThumbExt != ".png" {
+		t.Errorf("gif thumbnail ext = %q, expected .png", img.ThumbExt)
+	}
+}
+
+// gifWithFrames builds a GIF of n w x h frames whose pixel data is a single
+// empty sub-block, enough to walk but not to decode.
+func gifWithFrames(n, w, h int) []byte {
+	le := binary.LittleEndian
+	data := []byte("GIF89a")
+	data = le.AppendUint16(data, uint16(w))
+	data = le.AppendUint16(data, uint16(h))
+	data = append(data, 0x80, 0, 0, 0, 0, 0, 0xff, 0xff, 0xff)
+	for i := 0; i < n; i++ {
+		data = append(data, 0x2c, 0, 0, 0, 0)
+		data = le.AppendUint16(data, uint16(w))
+		data = le.AppendUint16(data, uint16(h))
+		data = append(data, 0, 2, 1, 0, 0)
+	}
+	return append(data, 0x3b)
+}
+
+func TestCheckGIFFrames(t *testing.T) {
+	cases := []struct {
+		name string
+		data []byte
+		ok   bool
+	}{
+		{"small", gifWithFrames(3, 10, 10), true},
+		{"max frames", gifWithFrames(MaxFrames, 10, 10), true},
+		{"too many frames", gifWithFrames(MaxFrames+1, 10, 10), false},
+		// each frame is under MaxPixels, together they are not
+		{"too many pixels", gifWithFrames(2, 6000, 6000), false},
+		{"truncated", gifWithFrames(2, 10, 10)[:30], false},
+	}
+
+	for _, c := range cases {
+		err := checkGIFFrames(c.data)
+		if (err == nil) != c.ok {
+			t.Errorf("%s: checkGIFFrames error = %v, expected ok = %v", c.name, err, c.ok)
+		}
+	}
+}
+
+func TestProcessRejectsGIFsTooLargeToDecode(t *testing.T) {
+	_, err := Process(gifWithFrames(2, 6000, 6000))
+	if err == nil {
+		t.Error("Process accepted 2 6000x6000 frames, expected an error")
+	}
+}
+
+func TestProcessRejectsOtherTypes(t *testing.T) {
+	cases := [][]byte{
+		[]byte("just some text"),
+		[]byte("<svg xmlns=\"http://www.w3.org/2000/svg\"></svg>"),
+		[]byte("\x89PNG\r\n\x1a\nnot really a png"),
+	}
+
+	for _, c := range cases {
+		_, err := Process(c)
+		if err == nil {
+			t.Errorf("Process(%q) succeeded, expected an error", c)
+		}
+	}
+
+	_, err := Process([]byte("plain text"))
+	if !errors.Is(err, ErrUnsupportedType) {
+		t.Errorf("Process(text) error = %v, expected ErrUnsupportedType", err)
+	}
+}
+
+func TestOrientSwapsDimensions(t *testing.T) {
+	src := testImage(3, 2)
+
+	for orientation := 1; orientation <= 8; orientation++ {
+		b := orient(src, orientation).Bounds()
+		w, h := 3, 2
+		if orientation >= 5 {
+			w, h = 2, 3
+		}
+		if b.Dx() != w || b.Dy() != h {
+			t.Errorf("orient(%d) size = %dx%d, expected %dx%d", orientation, b.Dx(), b.Dy(), w, h)
+		}
+	}
+
+	// rotating clockwise puts the bottom-left pixel in the top-left corner
+	got := color.RGBA64Model.Convert(orient(src, 6).At(0, 0))
+	want := color.RGBA64Model.Convert(src.At(0, 1))
+	if got != want {
+		t.Errorf("orient(6) top-left = %v, expected %v", got, want)
+	}
+}
@@ -0,0 +1,114 @@
+package media
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/shahanmmiah/Chirpy/internal/database"
+)
+
+// BlobStore keeps the bytes of uploaded files. Keys are chosen by the caller
+// and are flat names rather than paths.
+type BlobStore interface {
+	Put(ctx context.Context, key string, r io.Reader) error
+	Delete(ctx context.Context, key string) error
+	URL(key string) string
+}
+
+// DeleteBlobs removes the stored files behind media rows that are already
+// gone from the database. Failures only leave orphaned files, so they are
+// logged rather than returned.
+func DeleteBlobs(ctx context.Context, store BlobStore, mediaDb []database.MediaAttachment) {
+	for _, m := range mediaDb {
+		for _, key := range []string{m.BlobKey, m.ThumbKey} {
+			if err := store.Delete(ctx, key); err != nil {
+				log.Printf("media: deleting %v: %v", key, err)
+			}
+		}
+	}
+}
+
+// LocalStore keeps blobs as files in a single directory and serves them
+// itself, so it can be mounted next to the frontend file server.
+type LocalStore struct {
+	dir     string
+	baseURL string
+}
+
+func NewLocalStore(dir, baseURL string) (*LocalStore, error) {
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return nil, err
+	}
+	return &LocalStore{dir: dir, baseURL: strings.TrimSuffix(baseURL, "/")}, nil
+}
+
+// validKey rejects anything that could escape the directory or name one of
+// the hidden temporary files written by Put.
+func validKey(key string) error {
+	if key == "" || key != filepath.Base(key) || strings.HasPrefix(key, ".") {
+		return fmt.Errorf("invalid blob key %q", key)
+	}
+	return nil
+}
+
+// Put writes to a temporary file first so a reader never sees half a blob.
+func (s *LocalStore) Put(ctx context.Context, key string, r io.Reader) error {
+	if err := validKey(key); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(s.dir, ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = io.Copy(tmp, r)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), filepath.Join(s.dir, key))
+}
+
+// Delete does not treat a missing blob as an error.
+func (s *LocalStore) Delete(ctx context.Context, key string) error {
+	if err := validKey(key); err != nil {
+		return err
+	}
+
+	err := os.Remove(filepath.Join(s.dir, key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func (s *LocalStore) URL(key string) string {
+	return s.baseURL + "/" + key
+}
+
+// ServeHTTP serves single blobs by key. Unlike http.FileServer it never lists
+// the directory. Keys are never reused, so responses can be cached forever.
+func (s *LocalStore) ServeHTTP(resp http.ResponseWriter, req *http.Request) {
+	key := strings.TrimPrefix(req.URL.Path, "/")
+	if validKey(key) != nil {
+		http.NotFound(resp, req)
+		return
+	}
+
+	resp.Header().Set("X-Content-Type-Options", "nosniff")
+	resp.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	http.ServeFile(resp, req, filepath.Join(s.dir, key))
+}
@@ -0,0 +1,85 @@
+package media
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/shahanmmiah/Chirpy/internal/database"
+)
+
+func TestLocalStore(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewLocalStore(t.TempDir(), "/app/media/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.Put(ctx, "abc.png", strings.NewReader("blob")); err != nil {
+		t.Fatal(err)
+	}
+	if got := store.URL("abc.png"); got != "/app/media/abc.png" {
+		t.Errorf("URL = %q, expected /app/media/abc.png", got)
+	}
+
+	for _, key := range []string{"", "../abc.png", "sub/abc.png", ".upload-1"} {
+		if err := store.Put(ctx, key, strings.NewReader("blob")); err == nil {
+			t.Errorf("Put(%q) succeeded, expected an error", key)
+		}
+	}
+
+	cases := []struct {
+		InputPath    string
+		ExpectedCode int
+	}{
+		{InputPath: "/abc.png", ExpectedCode: http.StatusOK},
+		{InputPath: "/", ExpectedCode: http.StatusNotFound},
+		{InputPath: "/missing.png", ExpectedCode: http.StatusNotFound},
+		{InputPath: "/.upload-1", ExpectedCode: http.StatusNotFound},
+	}
+	for _, c := range cases {
+		rec := httptest.NewRecorder()
+		store.ServeHTTP(rec, httptest.NewRequest("GET", c.InputPath, nil))
+		if rec.Code != c.ExpectedCode {
+			t.Errorf("GET %s = %d, expected %d", c.InputPath, rec.Code, c.ExpectedCode)
+		}
+	}
+
+	if err := store.Delete(ctx, "abc.png"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Delete(ctx, "abc.png"); err != nil {
+		t.Errorf("Delete of a missing blob = %v, expected nil", err)
+	}
+}
+
+func TestDeleteBlobs(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	store, err := NewLocalStore(dir, "/app/media/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the second row's thumbnail was never written
+	for _, key := range []string{"a.png", "a-thumb.png", "b.png"} {
+		if err := store.Put(ctx, key, strings.NewReader("blob")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	DeleteBlobs(ctx, store, []database.MediaAttachment{
+		{BlobKey: "a.png", ThumbKey: "a-thumb.png"},
+		{BlobKey: "b.png", ThumbKey: "b-thumb.png"},
+	})
+
+	for _, key := range []string{"a.png", "a-thumb.png", "b.png"} {
+		if _, err := os.Stat(filepath.Join(dir, key)); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("blob %v still stored (%v)", key, err)
+		}
+	}
+}
@@ -0,0 +1,254 @@
+package moderation
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+type Policy string
+
+const (
+	PolicyCensor Policy = "censor"
+	PolicyHold   Policy = "hold"
+	PolicyReject Policy = "reject"
+)
+
+func ParsePolicy(raw string) (Policy, error) {
+	switch Policy(strings.ToLower(raw)) {
+	case "", PolicyCensor:
+		return PolicyCensor, nil
+	case PolicyHold:
+		return PolicyHold, nil
+	case PolicyReject:
+		return PolicyReject, nil
+	}
+	return "", fmt.Errorf("unknown moderation policy %q", raw)
+}
+
+func (p Policy) severity() int {
+	switch p {
+	case PolicyCensor:
+		return 1
+	case PolicyHold:
+		return 2
+	case PolicyReject:
+		return 3
+	}
+	return 0
+}
+
+// Rule flags a word. A trailing '*' turns it into a prefix match, so
+// "kerfuffle*" also catches "kerfuffled".
+type Rule struct {
+	Word   string
+	Policy Policy
+}
+
+type Match struct {
+	Text   string
+	Rule   Rule
+	Offset int
+	Length int
+}
+
+type Result struct {
+	Text    string
+	Action  Policy
+	Matches []Match
+}
+
+func (r Result) MatchedWords() []string {
+	words := []string{}
+	for _, m := range r.Matches {
+		words = append(words, m.Rule.Word)
+	}
+	return words
+}
+
+// Merge folds the matches of other into r, keeping r's text, so several
+// fields of one post can be moderated as a whole.
+func (r Result) Merge(other Result) Result {
+	r.Matches = append(append([]Match{}, r.Matches...), other.Matches...)
+	if other.Action.severity() > r.Action.severity() {
+		r.Action = other.Action
+	}
+	return r
+}
+
+// Filter checks text against a word list that can be swapped at runtime.
+type Filter struct {
+	mu       sync.RWMutex
+	censor   string
+	exact    map[string]Rule
+	prefixes []Rule
+	allow    map[string]bool
+}
+
+func NewFilter(censor string) *Filter {
+	return &Filter{
+		censor: censor,
+		exact:  map[string]Rule{},
+		allow:  map[string]bool{},
+	}
+}
+
+// Replace swaps the whole rule set and allow-list in one step.
+func (f *Filter) Replace(rules []Rule, allow []string) {
+	exact := map[string]Rule{}
+	prefixes := []Rule{}
+	for _, rule := range rules {
+		word, isPrefix := strings.CutSuffix(Normalize(strings.TrimSpace(rule.Word)), "*")
+		if word == "" {
+			continue
+		}
+		if isPrefix {
+			prefixes = append(prefixes, Rule{Word: word, Policy: rule.Policy})
+			continue
+		}
+		if existing, found := exact[word]; found && existing.Policy.severity() > rule.Policy.severity() {
+			continue
+		}
+		exact[word] = Rule{Word: word, Policy: rule.Policy}
+	}
+
+	// longest prefix first so the most specific rule wins
+	sort.Slice(prefixes, func(i, j int) bool {
+		return len(prefixes[i].Word) > len(prefixes[j].Word)
+	})
+
+	allowed := map[string]bool{}
+	for _, word := range allow {
+		if word = Normalize(strings.TrimSpace(word)); word != "" {
+			allowed[word] = true
+		}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.exact = exact
+	f.prefixes = prefixes
+	f.allow = allowed
+}
+
+func (f *Filter) lookup(word string) (Rule, bool) {
+	if f.allow[word] {
+		return Rule{}, false
+	}
+	if rule, found := f.exact[word]; found {
+		return rule, true
+	}
+	for _, rule := range f.prefixes {
+		if strings.HasPrefix(word, rule.Word) {
+			return rule, true
+		}
+	}
+	return Rule{}, false
+}
+
+// Check reports every flagged word in text, the strictest policy among them,
+// and text with the censor-policy words masked.
+func (f *Filter) Check(text string) Result {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	result := Result{Text: text}
+	for _, t := range tokenize(text) {
+		candidates := []token{t}
+		if trimmed := trimSymbols(text, t); trimmed != t && trimmed.start < trimmed.end {
+			candidates = append(candidates, trimmed)
+		}
+
+		for _, candidate := range candidates {
+			rule, found := f.lookup(Normalize(text[candidate.start:candidate.end]))
+			if !found {
+				continue
+			}
+			result.Matches = append(result.Matches, Match{
+				Text:   text[candidate.start:candidate.end],
+				Rule:   rule,
+				Offset: candidate.start,
+				Length: candidate.end - candidate.start})
+			if rule.Policy.severity() > result.Action.severity() {
+				result.Action = rule.Policy
+			}
+			break
+		}
+	}
+
+	var cleanStr strings.Builder
+	last := 0
+	for _, m := range result.Matches {
+		if m.Rule.Policy != PolicyCensor {
+			continue
+		}
+		cleanStr.WriteString(text[last:m.Offset])
+		cleanStr.WriteString(f.censor)
+		last = m.Offset + m.Length
+	}
+	cleanStr.WriteString(text[last:])
+	result.Text = cleanStr.String()
+
+	return result
+}
+
+// ParseList reads a word list with one entry per line:
+//
+//	# comment
+//	kerfuffle           censored (the default policy)
+//	sharbert reject
+//	fornax* hold
+//	!fornaxian          allowed even though it matches a rule
+func ParseList(r io.Reader) ([]Rule, []string, error) {
+	rules := []Rule{}
+	allow := []string{}
+
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if word, found := strings.CutPrefix(line, "!"); found {
+			allow = append(allow, strings.TrimSpace(word))
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) > 2 {
+			return nil, nil, fmt.Errorf("line %d: expected a word and an optional policy", lineNum)
+		}
+
+		policy := PolicyCensor
+		if len(fields) == 2 {
+			var err error
+			policy, err = ParsePolicy(fields[1])
+			if err != nil {
+				return nil, nil, fmt.Errorf("line %d: %v", lineNum, err)
+			}
+		}
+		rules = append(rules, Rule{Word: fields[0], Policy: policy})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+	return rules, allow, nil
+}
+
+func LoadFile(path string) ([]Rule, []string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+
+	return ParseList(file)
+}
@@ -0,0 +1,162 @@
+package moderation
+
+import (
+	"strings"
+	"testing"
+)
+
+func defaultFilter() *Filter {
+	f := NewFilter("****")
+	f.Replace([]Rule{
+		{Word: "kerfuffle", Policy: PolicyCensor},
+		{Word: "sharbert", Policy: PolicyCensor},
+		{Word: "fornax*", Policy: PolicyCensor},
+		{Word: "scam", Policy: PolicyHold},
+		{Word: "slur", Policy: PolicyReject},
+	}, []string{"fornaxian"})
+	return f
+}
+
+func TestCheckCensors(t *testing.T) {
+	cases := []struct {
+		InputText    string
+		ExpectedText string
+	}{
+		{
+			InputText:    "This is a kerfuffle opinion I need to share with the world",
+			ExpectedText: "This is a **** opinion I need to share with the world"},
+		{
+			InputText:    "Kerfuffle! What a Sharbert.",
+			ExpectedText: "****! What a ****."},
+		{
+			InputText:    "k3rfuffl3 and SH4RB3RT",
+			ExpectedText: "**** and ****"},
+		{
+			InputText:    "ｋｅｒｆｕｆｆｌｅ with kérfuffle",
+			ExpectedText: "**** with ****"},
+		{
+			InputText:    "a decomposed ke\u0301rfuffle",
+			ExpectedText: "a decomposed ****"},
+		{
+			InputText:    "ＫＥＲＦＵＦＦＬＥ and ｓｈ４ｒｂｅｒｔ",
+			ExpectedText: "**** and ****"},
+		{
+			InputText:    "kerfuﬄe and 𝐤𝐞𝐫𝐟𝐮𝐟𝐟𝐥𝐞",
+			ExpectedText: "**** and ****"},
+		{
+			InputText:    "stacked k\u0308\u0301erfuffle\u0327 and ŝhàrbërt",
+			ExpectedText: "stacked **** and ****"},
+		{
+			InputText:    "@kerfuffle said so",
+			ExpectedText: "@**** said so"},
+		{
+			InputText:    "fornaxes everywhere but the fornaxian is fine",
+			ExpectedText: "**** everywhere but the fornaxian is fine"},
+		{
+			InputText:    "nothing to see here",
+			ExpectedText: "nothing to see here"},
+	}
+	f := defaultFilter()
+	for _, c := range cases {
+
+		result := f.Check(c.InputText)
+
+		if result.Text != c.ExpectedText {
+			t.Errorf("error text is '%s' should be '%s'", result.Text, c.ExpectedText)
+		}
+
+	}
+}
+
+func TestCheckPolicy(t *testing.T) {
+	cases := []struct {
+		InputText      string
+		ExpectedAction Policy
+	}{
+		{
+			InputText:      "all clean",
+			ExpectedAction: ""},
+		{
+			InputText:      "a kerfuffle",
+			ExpectedAction: PolicyCensor},
+		{
+			InputText:      "a kerfuffle and a 5c4m",
+			ExpectedAction: PolicyHold},
+		{
+			InputText:      "scam slur kerfuffle",
+			ExpectedAction: PolicyReject},
+	}
+	f := defaultFilter()
+	for _, c := range cases {
+
+		result := f.Check(c.InputText)
+
+		if result.Action != c.ExpectedAction {
+			t.Errorf("error action is '%s' should be '%s' for '%s'", result.Action, c.ExpectedAction, c.InputText)
+		}
+
+	}
+}
+
+func TestResultMerge(t *testing.T) {
+	f := defaultFilter()
+
+	body := f.Check("a kerfuffle")
+	merged := body.Merge(f.Check("clean")).Merge(f.Check("a scam"))
+
+	if merged.Action != PolicyHold {
+		t.Errorf("error merged action is '%s' should be '%s'", merged.Action, PolicyHold)
+	}
+	if merged.Text != body.Text {
+		t.Errorf("error merged text is '%s' should be '%s'", merged.Text, body.Text)
+	}
+	if words := strings.Join(merged.MatchedWords(), ","); words != "kerfuffle,scam" {
+		t.Errorf("error merged words are '%s' should be 'kerfuffle,scam'", words)
+	}
+}
+
+func TestReplaceAtRuntime(t *testing.T) {
+	f := defaultFilter()
+
+	if f.Check("brand new word").Action != "" {
+		t.Error("error text should be clean before the rule is added")
+	}
+
+	f.Replace([]Rule{{Word: "brand", Policy: PolicyReject}}, nil)
+
+	if f.Check("brand new word").Action != PolicyReject {
+		t.Error("error new rule was not applied")
+	}
+
+	if f.Check("a kerfuffle").Action != "" {
+		t.Error("error old rules should be gone after replace")
+	}
+}
+
+func TestParseList(t *testing.T) {
+	list := `
+# default chirpy words
+kerfuffle
+sharbert reject
+fornax* hold
+!fornaxian
+`
+	rules, allow, err := ParseList(strings.NewReader(list))
+	if err != nil {
+		t.Errorf("error parsing list: %s", err.Error())
+		return
+	}
+
+	if len(rules) != 3 || rules[0].Policy != PolicyCensor || rules[1].Policy != PolicyReject || rules[2].Word != "fornax*" {
+		t.Errorf("error unexpected rules %v", rules)
+	}
+
+	if len(allow) != 1 || allow[0] != "fornaxian" {
+		t.Errorf("error unexpected allow list %v", allow)
+	}
+
+	_, _, err = ParseList(strings.NewReader("kerfuffle banish"))
+	if err == nil {
+		t.Error("error should not be nil for an unknown policy")
+	}
+}
@@ -0,0 +1,97 @@
+package moderation
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// leetLetters undoes the usual digit and symbol substitutions.
+var leetLetters = map[rune]rune{
+	'0': 'o',
+	'1': 'i',
+	'3': 'e',
+	'4': 'a',
+	'5': 's',
+	'7': 't',
+	'8': 'b',
+	'@': 'a',
+	'$': 's',
+}
+
+// newFolder lower-cases, strips accents and maps width and compatibility
+// variants ("ｋ", "ﬄ") to their plain letters. Decomposing first lets the
+// combining marks be dropped, so "ke\u0301rfuffle" and "kérfuffle" fold alike.
+// Transformers keep state, so each call gets its own chain.
+func newFolder() transform.Transformer {
+	return transform.Chain(norm.NFKD, runes.Remove(runes.In(unicode.Mn)), norm.NFKC, cases.Fold())
+}
+
+// Normalize reduces word to the form rules are stored and compared in:
+// case-folded, accent-free and with leetspeak substitutions undone.
+func Normalize(word string) string {
+	folded, _, err := transform.String(newFolder(), word)
+	if err != nil {
+		folded = strings.ToLower(word)
+	}
+	return strings.Map(func(r rune) rune {
+		if letter, found := leetLetters[r]; found {
+			return letter
+		}
+		return r
+	}, folded)
+}
+
+func isWordRune(r rune) bool {
+	if unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r) {
+		return true
+	}
+	folded := norm.NFKC.String(string(r))
+	return folded == "@" || folded == "$"
+}
+
+type token struct {
+	start int
+	end   int
+}
+
+// tokenize splits text into runs of word runes, so punctuation on either
+// side of a word ("Kerfuffle!") does not hide it.
+func tokenize(text string) []token {
+	tokens := []token{}
+	start := -1
+
+	for i, r := range text {
+		if isWordRune(r) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 {
+			tokens = append(tokens, token{start: start, end: i})
+			start = -1
+		}
+	}
+
+	if start >= 0 {
+		tokens = append(tokens, token{start: start, end: len(text)})
+	}
+	return tokens
+}
+
+// trimSymbols drops leading and trailing '@' and '$' so "@kerfuffle" is
+// checked as a word while "$h!t"-style spellings keep their interior symbols.
+func trimSymbols(text string, t token) token {
+	for t.start < t.end && (text[t.start] == '@' || text[t.start] == '$') {
+		t.start++
+	}
+	for t.end > t.start && (text[t.end-1] == '@' || text[t.end-1] == '$') {
+		t.end--
+	}
+	return t
+}
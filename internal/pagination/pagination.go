@@ -0,0 +1,118 @@
+package pagination
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const DefaultLimit = 50
+const MaxLimit = 100
+
+// Cursor marks the last row of a page in (created_at, id) order.
+type Cursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+type Page struct {
+	Limit  int32
+	Cursor *Cursor
+}
+
+func (c Cursor) Encode() string {
+	raw := fmt.Sprintf("%s|%s", c.CreatedAt.UTC().Format(time.RFC3339Nano), c.ID.String())
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func DecodeCursor(token string) (Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return Cursor{}, fmt.Errorf("invalid cursor")
+	}
+
+	createdAt, id, found := strings.Cut(string(raw), "|")
+	if !found {
+		return Cursor{}, fmt.Errorf("invalid cursor")
+	}
+
+	cursor := Cursor{}
+	cursor.CreatedAt, err = time.Parse(time.RFC3339Nano, createdAt)
+	if err != nil {
+		return Cursor{}, fmt.Errorf("invalid cursor")
+	}
+
+	cursor.ID, err = uuid.Parse(id)
+	if err != nil {
+		return Cursor{}, fmt.Errorf("invalid cursor")
+	}
+
+	return cursor, nil
+}
+
+// LimitFromQuery reads ?limit=, capped at MaxLimit.
+func LimitFromQuery(query url.Values) (int32, error) {
+	rawLimit := query.Get("limit")
+	if rawLimit == "" {
+		return DefaultLimit, nil
+	}
+
+	limit, err := strconv.Atoi(rawLimit)
+	if err != nil || limit < 1 {
+		return 0, fmt.Errorf("limit must be a positive number")
+	}
+	return int32(min(limit, MaxLimit)), nil
+}
+
+func FromQuery(query url.Values) (Page, error) {
+	limit, err := LimitFromQuery(query)
+	if err != nil {
+		return Page{}, err
+	}
+	page := Page{Limit: limit}
+
+	if rawCursor := query.Get("cursor"); rawCursor != "" {
+		cursor, err := DecodeCursor(rawCursor)
+		if err != nil {
+			return Page{}, err
+		}
+		page.Cursor = &cursor
+	}
+
+	return page, nil
+}
+
+// FetchLimit asks for one extra row so callers can tell if another page exists.
+func (p Page) FetchLimit() int32 {
+	return p.Limit + 1
+}
+
+func (p Page) CursorCreatedAt() sql.NullTime {
+	if p.Cursor == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: p.Cursor.CreatedAt, Valid: true}
+}
+
+func (p Page) CursorID() uuid.NullUUID {
+	if p.Cursor == nil {
+		return uuid.NullUUID{}
+	}
+	return uuid.NullUUID{UUID: p.Cursor.ID, Valid: true}
+}
+
+// NextLink builds an RFC 8288 Link header value pointing at the page that
+// starts after the encoded cursor.
+func NextLink(current *url.URL, cursor string) string {
+	query := current.Query()
+	query.Set("cursor", cursor)
+
+	next := url.URL{Path: current.Path, RawQuery: query.Encode()}
+	return fmt.Sprintf(`<%s>; rel="next"`, next.String())
+}
@@ -0,0 +1,118 @@
+package pagination
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestCursorEncodeDecode(t *testing.T) {
+	cases := []struct {
+		InputCursor Cursor
+	}{
+		{
+			InputCursor: Cursor{CreatedAt: time.Date(2025, 3, 4, 5, 6, 7, 123456000, time.UTC), ID: uuid.New()}},
+		{
+			InputCursor: Cursor{CreatedAt: time.Unix(0, 0).UTC(), ID: uuid.Nil}},
+	}
+	for _, c := range cases {
+
+		actual, err := DecodeCursor(c.InputCursor.Encode())
+		if err != nil {
+			t.Errorf("error decoding cursor: %s", err.Error())
+		}
+
+		if !actual.CreatedAt.Equal(c.InputCursor.CreatedAt) || actual.ID != c.InputCursor.ID {
+			t.Errorf("error decoded cursor %v differs from input %v", actual, c.InputCursor)
+		}
+
+	}
+}
+
+func TestDecodeCursorInvalid(t *testing.T) {
+	cases := []string{"", "!!!", "bm90LWEtY3Vyc29y"}
+	for _, c := range cases {
+
+		_, err := DecodeCursor(c)
+		if err == nil {
+			t.Errorf("error should not be nil for cursor '%s'", c)
+		}
+
+	}
+}
+
+func TestFromQuery(t *testing.T) {
+	cases := []struct {
+		InputQuery    string
+		ExpectedLimit int32
+		ExpectErr     bool
+	}{
+		{
+			InputQuery:    "",
+			ExpectedLimit: DefaultLimit},
+		{
+			InputQuery:    "limit=10",
+			ExpectedLimit: 10},
+		{
+			InputQuery:    "limit=100000",
+			ExpectedLimit: MaxLimit},
+		{
+			InputQuery: "limit=0",
+			ExpectErr:  true},
+		{
+			InputQuery: "limit=ten",
+			ExpectErr:  true},
+		{
+			InputQuery: "cursor=nope",
+			ExpectErr:  true},
+	}
+	for _, c := range cases {
+
+		query, _ := url.ParseQuery(c.InputQuery)
+		page, err := FromQuery(query)
+
+		if c.ExpectErr {
+			if err == nil {
+				t.Errorf("error should not be nil for query '%s'", c.InputQuery)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("error parsing query '%s': %s", c.InputQuery, err.Error())
+		}
+
+		if page.Limit != c.ExpectedLimit {
+			t.Errorf("error limit is %d should be %d", page.Limit, c.ExpectedLimit)
+		}
+
+	}
+}
+
+func TestNextLink(t *testing.T) {
+	current, _ := url.Parse("/api/chirps?author_id=abc&limit=5&cursor=old")
+	cursor := Cursor{CreatedAt: time.Now().UTC(), ID: uuid.New()}
+
+	link := NextLink(current, cursor.Encode())
+
+	if !strings.HasSuffix(link, `>; rel="next"`) {
+		t.Errorf("error link '%s' is missing rel=next", link)
+	}
+
+	next, err := url.Parse(strings.TrimSuffix(strings.TrimPrefix(link, "<"), `>; rel="next"`))
+	if err != nil {
+		t.Errorf("error parsing link: %s", err.Error())
+		return
+	}
+
+	if next.Query().Get("cursor") != cursor.Encode() {
+		t.Errorf("error next cursor is '%s' should be '%s'", next.Query().Get("cursor"), cursor.Encode())
+	}
+
+	if next.Query().Get("author_id") != "abc" || next.Query().Get("limit") != "5" {
+		t.Errorf("error next link '%s' dropped the original filters", link)
+	}
+}
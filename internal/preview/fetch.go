@@ -0,0 +1,163 @@
+// Package preview builds link preview cards for chirps. Pages are fetched in
+// the background by a Worker using a Fetcher that refuses to connect to
+// private or otherwise internal addresses.
+package preview
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+// Timeout bounds a whole fetch, redirects and body included.
+const Timeout = 5 * time.Second
+
+const DialTimeout = 2 * time.Second
+
+// MaxBytes is how much of a page is read. The metadata lives in the <head>,
+// so anything past this is not needed.
+const MaxBytes = 512 << 10
+
+const MaxRedirects = 3
+
+const UserAgent = "Chirpy-LinkPreview/1.0"
+
+var ErrBlockedAddress = errors.New("address is not publicly routable")
+
+// blockedPrefixes are ranges that netip's own predicates do not cover but
+// that are still not somewhere a preview should be fetched from.
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("192.0.2.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("198.51.100.0/24"),
+	netip.MustParsePrefix("203.0.113.0/24"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+	netip.MustParsePrefix("2001:db8::/32"),
+}
+
+// IsPublic reports whether addr is safe to fetch previews from.
+func IsPublic(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsValid() || addr.IsUnspecified() || addr.IsLoopback() || addr.IsPrivate() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() || addr.IsMulticast() {
+		return false
+	}
+
+	for _, p := range blockedPrefixes {
+		if p.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+type Fetcher struct {
+	client *http.Client
+}
+
+func NewFetcher() *Fetcher {
+	return newFetcher(IsPublic)
+}
+
+// newFetcher checks every address at connect time, after DNS resolution, so
+// a hostname cannot pass a check and then resolve somewhere else. That also
+// covers each hop of a redirect. Tests use it to let through the loopback
+// address of an httptest server.
+func newFetcher(allowed func(netip.Addr) bool) *Fetcher {
+	dialer := &net.Dialer{
+		Timeout: DialTimeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			addr, err := netip.ParseAddr(host)
+			if err != nil {
+				return err
+			}
+			if !allowed(addr) {
+				return fmt.Errorf("%v: %w", addr, ErrBlockedAddress)
+			}
+			return nil
+		},
+	}
+
+	transport := &http.Transport{
+		// an environment proxy would make the connection checks meaningless
+		Proxy:                 nil,
+		DialContext:           dialer.DialContext,
+		TLSHandshakeTimeout:   DialTimeout,
+		ResponseHeaderTimeout: Timeout,
+		MaxIdleConns:          10,
+		IdleConnTimeout:       30 * time.Second,
+	}
+
+	return &Fetcher{client: &http.Client{
+		Transport: transport,
+		Timeout:   Timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) > MaxRedirects {
+				return fmt.Errorf("more than %d redirects", MaxRedirects)
+			}
+			if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+				return fmt.Errorf("redirect to unsupported scheme %q", req.URL.Scheme)
+			}
+			return nil
+		},
+	}}
+}
+
+// Fetch downloads rawURL and parses a card from it. Only HTML pages are read.
+func (f *Fetcher) Fetch(ctx context.Context, rawURL string) (Card, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return Card{}, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return Card{}, fmt.Errorf("unsupported scheme %q", u.Scheme)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
+	if err != nil {
+		return Card{}, err
+	}
+	req.Header.Set("User-Agent", UserAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return Card{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return Card{}, fmt.Errorf("fetching %v: %v", u, resp.Status)
+	}
+
+	mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil || (mediaType != "text/html" && mediaType != "application/xhtml+xml") {
+		return Card{}, fmt.Errorf("fetching %v: not an html page", u)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, MaxBytes))
+	if err != nil {
+		return Card{}, err
+	}
+
+	// resp.Request is the last hop, so relative links resolve against where
+	// the page really came from
+	return Parse(string(body), resp.Request.URL), nil
+}
@@ -0,0 +1,137 @@
+package preview
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+)
+
+const testPage = `<!doctype html>
+<html><head>
+<title>Fallback title</title>
+<meta property="og:title" content="A &amp; B">
+<meta property="og:description" content="  spread
+  over lines ">
+<meta property="og:image" content="/img/card.png">
+<meta name="twitter:title" content="Twitter title">
+</head><body><meta property="og:title" content="not in the head"></body></html>`
+
+func testSite() *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/page", func(resp http.ResponseWriter, req *http.Request) {
+		resp.Header().Set("Content-Type", "text/html; charset=utf-8")
+		resp.Write([]byte(testPage))
+	})
+	mux.HandleFunc("/moved", func(resp http.ResponseWriter, req *http.Request) {
+		http.Redirect(resp, req, "/page", http.StatusFound)
+	})
+	mux.HandleFunc("/loop", func(resp http.ResponseWriter, req *http.Request) {
+		http.Redirect(resp, req, "/loop", http.StatusFound)
+	})
+	mux.HandleFunc("/file", func(resp http.ResponseWriter, req *http.Request) {
+		resp.Header().Set("Content-Type", "application/zip")
+		resp.Write([]byte("PK"))
+	})
+	mux.HandleFunc("/huge", func(resp http.ResponseWriter, req *http.Request) {
+		resp.Header().Set("Content-Type", "text/html")
+		resp.Write([]byte(strings.Repeat(" ", MaxBytes)))
+		resp.Write([]byte(`<title>past the limit</title>`))
+	})
+	return httptest.NewServer(mux)
+}
+
+func allowAll(netip.Addr) bool { return true }
+
+func TestFetchParsesCard(t *testing.T) {
+	site := testSite()
+	defer site.Close()
+
+	f := newFetcher(allowAll)
+
+	for _, path := range []string{"/page", "/moved"} {
+		card, err := f.Fetch(context.Background(), site.URL+path)
+		if err != nil {
+			t.Fatalf("Fetch(%v) error = %v", path, err)
+		}
+
+		expected := Card{
+			Title:       "A & B",
+			Description: "spread over lines",
+			ImageURL:    site.URL + "/img/card.png",
+			SiteName:    "127.0.0.1",
+		}
+		if card != expected {
+			t.Errorf("Fetch(%v) = %+v, expected %+v", path, card, expected)
+		}
+	}
+}
+
+func TestFetchRejects(t *testing.T) {
+	site := testSite()
+	defer site.Close()
+
+	f := newFetcher(allowAll)
+
+	for _, path := range []string{"/loop", "/file", "/missing"} {
+		_, err := f.Fetch(context.Background(), site.URL+path)
+		if err == nil {
+			t.Errorf("Fetch(%v) succeeded, expected an error", path)
+		}
+	}
+
+	card, err := f.Fetch(context.Background(), site.URL+"/huge")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !card.Empty() {
+		t.Errorf("Fetch(/huge) read past the size cap: %+v", card)
+	}
+
+	_, err = f.Fetch(context.Background(), "file:///etc/passwd")
+	if err == nil {
+		t.Errorf("Fetch(file://) succeeded, expected an error")
+	}
+}
+
+func TestFetchBlocksPrivateAddresses(t *testing.T) {
+	site := testSite()
+	defer site.Close()
+
+	// the test server listens on loopback, which the real fetcher refuses
+	_, err := NewFetcher().Fetch(context.Background(), site.URL+"/page")
+	if !errors.Is(err, ErrBlockedAddress) {
+		t.Errorf("Fetch(loopback) error = %v, expected ErrBlockedAddress", err)
+	}
+}
+
+func TestIsPublic(t *testing.T) {
+	cases := []struct {
+		Input    string
+		Expected bool
+	}{
+		{Input: "93.184.216.34", Expected: true},
+		{Input: "2606:4700::1111", Expected: true},
+		{Input: "127.0.0.1", Expected: false},
+		{Input: "10.1.2.3", Expected: false},
+		{Input: "172.16.0.1", Expected: false},
+		{Input: "192.168.1.1", Expected: false},
+		{Input: "169.254.169.254", Expected: false},
+		{Input: "100.64.0.1", Expected: false},
+		{Input: "0.0.0.0", Expected: false},
+		{Input: "::1", Expected: false},
+		{Input: "fd00::1", Expected: false},
+		{Input: "fe80::1", Expected: false},
+		{Input: "::ffff:127.0.0.1", Expected: false},
+		{Input: "64:ff9b::a00:1", Expected: false},
+	}
+
+	for _, c := range cases {
+		if got := IsPublic(netip.MustParseAddr(c.Input)); got != c.Expected {
+			t.Errorf("IsPublic(%v) = %v, expected %v", c.Input, got, c.Expected)
+		}
+	}
+}
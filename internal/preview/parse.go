@@ -0,0 +1,103 @@
+package preview
+
+import (
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+const MaxTitleLen = 200
+const MaxDescriptionLen = 500
+
+// Card is the metadata shown under a chirp for its first link.
+type Card struct {
+	Title       string
+	Description string
+	ImageURL    string
+	SiteName    string
+}
+
+// Empty reports whether there is nothing worth showing.
+func (c Card) Empty() bool {
+	return c.Title == ""
+}
+
+var (
+	headEnd      = regexp.MustCompile(`(?i)</head\s*>`)
+	metaTag      = regexp.MustCompile(`(?is)<meta\s[^>]*>`)
+	titleTag     = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title\s*>`)
+	tagAttribute = regexp.MustCompile(`(?s)([a-zA-Z_:-]+)\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s"'>]+))`)
+)
+
+// Parse pulls OpenGraph and Twitter card metadata out of an HTML page,
+// falling back to <title> and the plain description meta tag. It reads only
+// the tags it needs rather than building a DOM, which is enough for the
+// <head> of real pages. Relative image URLs are resolved against page.
+func Parse(doc string, page *url.URL) Card {
+	if loc := headEnd.FindStringIndex(doc); loc != nil {
+		doc = doc[:loc[0]]
+	}
+
+	meta := map[string]string{}
+	for _, tag := range metaTag.FindAllString(doc, -1) {
+		attrs := map[string]string{}
+		for _, m := range tagAttribute.FindAllStringSubmatch(tag, -1) {
+			attrs[strings.ToLower(m[1])] = m[2] + m[3] + m[4]
+		}
+
+		key := attrs["property"]
+		if key == "" {
+			key = attrs["name"]
+		}
+		key = strings.ToLower(key)
+		// the first value wins, as it does in most card renderers
+		if _, seen := meta[key]; key != "" && !seen {
+			meta[key] = clean(attrs["content"])
+		}
+	}
+
+	title := ""
+	if m := titleTag.FindStringSubmatch(doc); m != nil {
+		title = clean(m[1])
+	}
+
+	card := Card{
+		Title:       truncate(first(meta["og:title"], meta["twitter:title"], title), MaxTitleLen),
+		Description: truncate(first(meta["og:description"], meta["twitter:description"], meta["description"]), MaxDescriptionLen),
+		SiteName:    truncate(first(meta["og:site_name"], page.Hostname()), MaxTitleLen),
+	}
+
+	image := first(meta["og:image:secure_url"], meta["og:image"], meta["twitter:image"], meta["twitter:image:src"])
+	if ref, err := url.Parse(image); image != "" && err == nil {
+		abs := page.ResolveReference(ref)
+		if abs.Scheme == "http" || abs.Scheme == "https" {
+			card.ImageURL = abs.String()
+		}
+	}
+
+	return card
+}
+
+func first(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// clean decodes entities and collapses the whitespace that pages wrap their
+// titles in.
+func clean(s string) string {
+	return strings.Join(strings.Fields(html.UnescapeString(s)), " ")
+}
+
+func truncate(s string, max int) string {
+	if utf8.RuneCountInString(s) <= max {
+		return s
+	}
+	return string([]rune(s)[:max-1]) + "…"
+}
@@ -0,0 +1,48 @@
+package preview
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	page, _ := url.Parse("https://example.com/posts/1")
+
+	cases := []struct {
+		Input    string
+		Expected Card
+	}{
+		{
+			Input:    `<title>Only a title</title>`,
+			Expected: Card{Title: "Only a title", SiteName: "example.com"}},
+		{
+			Input: `<meta name="twitter:title" content='Card title'><meta name="description" content="Plain description">
+				<meta name="twitter:image" content="https://cdn.example.com/a.png">`,
+			Expected: Card{Title: "Card title", Description: "Plain description", ImageURL: "https://cdn.example.com/a.png", SiteName: "example.com"}},
+		{
+			Input:    `<META PROPERTY="og:title" CONTENT="Upper case"><meta property="og:site_name" content="Example">`,
+			Expected: Card{Title: "Upper case", SiteName: "Example"}},
+		{
+			Input:    `<meta property="og:title" content="Bad image"><meta property="og:image" content="javascript:alert(1)">`,
+			Expected: Card{Title: "Bad image", SiteName: "example.com"}},
+		{
+			Input:    `<p>no metadata</p>`,
+			Expected: Card{SiteName: "example.com"}},
+	}
+
+	for _, c := range cases {
+		if got := Parse(c.Input, page); got != c.Expected {
+			t.Errorf("Parse(%q) = %+v, expected %+v", c.Input, got, c.Expected)
+		}
+	}
+}
+
+func TestParseTruncates(t *testing.T) {
+	page, _ := url.Parse("https://example.com/")
+	card := Parse(`<title>`+strings.Repeat("é", MaxTitleLen+10)+`</title>`, page)
+
+	if n := len([]rune(card.Title)); n != MaxTitleLen {
+		t.Errorf("Parse title length = %d, expected %d", n, MaxTitleLen)
+	}
+}
@@ -0,0 +1,107 @@
+package preview
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shahanmmiah/Chirpy/internal/chirptext"
+	"github.com/shahanmmiah/Chirpy/internal/database"
+)
+
+// ReuseFor is how long a fetched card is reused for other chirps linking to
+// the same URL before the page is fetched again.
+const ReuseFor = 24 * time.Hour
+
+type job struct {
+	chirpID uuid.UUID
+	url     string
+}
+
+// Worker fetches previews in the background so posting never waits on a
+// remote site.
+type Worker struct {
+	queries *database.Queries
+	fetcher *Fetcher
+	jobs    chan job
+}
+
+func NewWorker(queries *database.Queries, fetcher *Fetcher, queueSize int) *Worker {
+	return &Worker{
+		queries: queries,
+		fetcher: fetcher,
+		jobs:    make(chan job, queueSize),
+	}
+}
+
+// Chirp queues a preview of the first link in body, if there is one. Unlike
+// timeline fan-out a preview is optional, so when the queue is full the job
+// is dropped instead of holding up the request.
+func (w *Worker) Chirp(chirpID uuid.UUID, body string) {
+	links := chirptext.URLs(body)
+	if len(links) == 0 {
+		return
+	}
+
+	select {
+	case w.jobs <- job{chirpID: chirpID, url: links[0].Text}:
+	default:
+		log.Printf("preview: queue full, skipping %v", chirpID)
+	}
+}
+
+// Run handles queued jobs until ctx is cancelled. Jobs do not depend on each
+// other, so Run can be started on several goroutines to fetch in parallel.
+func (w *Worker) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case j := <-w.jobs:
+			if err := w.handle(ctx, j); err != nil {
+				log.Printf("preview: %v: %v", j.url, err)
+			}
+		}
+	}
+}
+
+func (w *Worker) handle(ctx context.Context, j job) error {
+	var card Card
+	fetchedAt := time.Now()
+
+	recent, err := w.queries.GetRecentLinkPreview(ctx, database.GetRecentLinkPreviewParams{
+		Url:          j.url,
+		FetchedAfter: time.Now().Add(-ReuseFor)})
+
+	switch {
+	case err == nil:
+		card = Card{Title: recent.Title, Description: recent.Description, ImageURL: recent.ImageUrl, SiteName: recent.SiteName}
+		// keep the original time, so a popular link is still refetched daily
+		fetchedAt = recent.FetchedAt
+
+	case errors.Is(err, sql.ErrNoRows):
+		card, err = w.fetcher.Fetch(ctx, j.url)
+		if err != nil {
+			return err
+		}
+
+	default:
+		return err
+	}
+
+	if card.Empty() {
+		return nil
+	}
+
+	return w.queries.CreateLinkPreview(ctx, database.CreateLinkPreviewParams{
+		ChirpID:     j.chirpID,
+		Url:         j.url,
+		Title:       card.Title,
+		Description: card.Description,
+		ImageUrl:    card.ImageURL,
+		SiteName:    card.SiteName,
+		FetchedAt:   fetchedAt})
+}
@@ -0,0 +1,111 @@
+// Package publisher promotes scheduled chirps once their publish time has
+// passed. Every server can run one: each batch is claimed with FOR UPDATE
+// SKIP LOCKED and flipped to published in the same statement, so a chirp is
+// published by exactly one of them.
+package publisher
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/shahanmmiah/Chirpy/internal/database"
+)
+
+// DefaultInterval is how often the database is checked for due chirps.
+const DefaultInterval = 15 * time.Second
+
+// BatchSize is how many chirps are claimed per transaction.
+const BatchSize = 100
+
+// Publisher publishes due chirps. Prepare runs inside the transaction that
+// claims each chirp, for anything that has to be written with it; it reports
+// false for a chirp it has held back, and if it fails the batch is rolled
+// back and tried again on the next tick. Published runs for each chirp that
+// went out once that transaction has committed.
+type Publisher struct {
+	db        *sql.DB
+	queries   *database.Queries
+	interval  time.Duration
+	prepare   func(ctx context.Context, queries *database.Queries, chirp database.Chirp) (bool, error)
+	published func(chirp database.Chirp)
+}
+
+func New(db *sql.DB, queries *database.Queries, interval time.Duration,
+	prepare func(context.Context, *database.Queries, database.Chirp) (bool, error),
+	published func(database.Chirp)) *Publisher {
+
+	return &Publisher{
+		db:        db,
+		queries:   queries,
+		interval:  interval,
+		prepare:   prepare,
+		published: published,
+	}
+}
+
+// Run publishes due chirps every interval until ctx is cancelled. A full
+// batch means more may be waiting, so those are drained straight away.
+func (p *Publisher) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			n, err := p.PublishDue(ctx, time.Now())
+			if err != nil {
+				log.Printf("publisher: %v", err)
+			}
+			if err != nil || n < BatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// PublishDue publishes one batch of chirps scheduled at or before now and
+// returns how many it claimed, whether they went out or were held back.
+func (p *Publisher) PublishDue(ctx context.Context, now time.Time) (int, error) {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	queries := p.queries.WithTx(tx)
+
+	chirps, err := queries.PublishDueChirps(ctx, database.PublishDueChirpsParams{
+		Now:      now,
+		RowLimit: BatchSize})
+
+	if err != nil {
+		return 0, err
+	}
+
+	var ready []database.Chirp
+	for _, chirp := range chirps {
+		ok, err := p.prepare(ctx, queries, chirp)
+		if err != nil {
+			return 0, err
+		}
+		if ok {
+			ready = append(ready, chirp)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	for _, chirp := range ready {
+		p.published(chirp)
+	}
+	return len(chirps), nil
+}
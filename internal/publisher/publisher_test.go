@@ -0,0 +1,110 @@
+package publisher
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	_ "github.com/lib/pq"
+	"github.com/shahanmmiah/Chirpy/internal/database"
+)
+
+// TestPublishDueOnce races several publishers, standing in for several
+// servers, over the same due chirps. It needs a migrated database in
+// CHIRPY_TEST_DB_URL.
+func TestPublishDueOnce(t *testing.T) {
+	dbURL := os.Getenv("CHIRPY_TEST_DB_URL")
+	if dbURL == "" {
+		t.Skip("CHIRPY_TEST_DB_URL not set")
+	}
+
+	db, err := sql.Open("postgres", dbURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	ctx := context.Background()
+	author := uuid.New()
+	now := time.Now()
+	const due = 3 * BatchSize
+
+	t.Cleanup(func() {
+		db.ExecContext(ctx, `DELETE FROM users WHERE id = $1`, author)
+	})
+
+	seed := []struct {
+		query string
+		args  []any
+	}{
+		{`INSERT INTO users(id, created_at, updated_at, email, hashed_password)
+			VALUES ($1, NOW(), NOW(), $2, 'unset')`, []any{author, "publisher-test-" + author.String()}},
+		{`INSERT INTO chirps(id, created_at, updated_at, body, user_id, status, publish_at)
+			SELECT gen_random_uuid(), $2, $2, 'scheduled chirp', $1, 'scheduled', $2::TIMESTAMP - n * INTERVAL '1 second'
+			FROM generate_series(1, $3::INT) n`, []any{author, now, due}},
+		{`INSERT INTO chirps(id, created_at, updated_at, body, user_id, status, publish_at)
+			VALUES (gen_random_uuid(), $2, $2, 'not yet', $1, 'scheduled', $2::TIMESTAMP + INTERVAL '1 hour')`, []any{author, now}},
+	}
+	for _, s := range seed {
+		if _, err := db.ExecContext(ctx, s.query, s.args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var mu sync.Mutex
+	published := map[uuid.UUID]int{}
+
+	queries := database.New(db)
+	newPublisher := func() *Publisher {
+		return New(db, queries, DefaultInterval,
+			func(context.Context, *database.Queries, database.Chirp) (bool, error) { return true, nil },
+			func(chirp database.Chirp) {
+				mu.Lock()
+				defer mu.Unlock()
+				if chirp.UserID == author {
+					published[chirp.ID]++
+				}
+			})
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(p *Publisher) {
+			defer wg.Done()
+			for {
+				n, err := p.PublishDue(ctx, now)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if n == 0 {
+					return
+				}
+			}
+		}(newPublisher())
+	}
+	wg.Wait()
+
+	if len(published) != due {
+		t.Errorf("published %d chirps, expected %d", len(published), due)
+	}
+	for id, n := range published {
+		if n != 1 {
+			t.Errorf("chirp %v published %d times", id, n)
+		}
+	}
+
+	var waiting int
+	err = db.QueryRowContext(ctx, `SELECT COUNT(*) FROM chirps WHERE user_id = $1 AND status = 'scheduled'`, author).Scan(&waiting)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if waiting != 1 {
+		t.Errorf("%d chirps still scheduled, expected 1", waiting)
+	}
+}
@@ -0,0 +1,177 @@
+// Package purge hard-deletes chirps and users once they have been soft-deleted
+// for longer than the restore window, along with uploads that were never
+// attached to a chirp. Like the publisher, every server can run one: rows are
+// claimed with FOR UPDATE SKIP LOCKED, so each is purged once.
+package purge
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shahanmmiah/Chirpy/internal/database"
+	"github.com/shahanmmiah/Chirpy/internal/fanout"
+	"github.com/shahanmmiah/Chirpy/internal/media"
+)
+
+// DefaultInterval is how often the database is checked for expired rows.
+const DefaultInterval = time.Hour
+
+// BatchSize is how many users, and separately chirps, are claimed per
+// transaction.
+const BatchSize = 100
+
+// UnattachedWindow is how long an upload may go without being attached to a
+// chirp before it is removed.
+const UnattachedWindow = 24 * time.Hour
+
+// Purger removes expired soft-deleted rows. Everything hanging off them goes
+// with them through ON DELETE CASCADE; counters kept on rows that survive,
+// like like_count and follower_count, are brought down first.
+type Purger struct {
+	db       *sql.DB
+	queries  *database.Queries
+	blobs    media.BlobStore
+	fanout   *fanout.Worker
+	window   time.Duration
+	interval time.Duration
+}
+
+func New(db *sql.DB, queries *database.Queries, blobs media.BlobStore, fanout *fanout.Worker, window, interval time.Duration) *Purger {
+	return &Purger{
+		db:       db,
+		queries:  queries,
+		blobs:    blobs,
+		fanout:   fanout,
+		window:   window,
+		interval: interval,
+	}
+}
+
+// Run purges expired rows every interval until ctx is cancelled, draining
+// full batches straight away.
+func (p *Purger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		for _, purge := range []func(context.Context, time.Time) (int, error){
+			p.PurgeExpired,
+			p.PurgeUnattached,
+		} {
+			for {
+				n, err := purge(ctx, time.Now())
+				if err != nil {
+					log.Printf("purge: %v", err)
+				}
+				if err != nil || n < BatchSize {
+					break
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// PurgeExpired hard-deletes one batch of users and chirps deleted more than
+// the window before now. It returns the size of the larger of the two
+// batches, so Run knows whether to go again.
+func (p *Purger) PurgeExpired(ctx context.Context, now time.Time) (int, error) {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	queries := p.queries.WithTx(tx)
+	before := sql.NullTime{Time: now.Add(-p.window), Valid: true}
+
+	userIds, err := queries.ClaimExpiredUsers(ctx, database.ClaimExpiredUsersParams{
+		DeletedBefore: before,
+		RowLimit:      BatchSize})
+
+	if err != nil {
+		return 0, err
+	}
+
+	chirpIds, err := queries.ClaimExpiredChirps(ctx, database.ClaimExpiredChirpsParams{
+		DeletedBefore: before,
+		RowLimit:      BatchSize})
+
+	if err != nil {
+		return 0, err
+	}
+
+	if len(userIds) == 0 && len(chirpIds) == 0 {
+		return 0, nil
+	}
+
+	mediaDb, err := queries.PurgeMedia(ctx, database.PurgeMediaParams{
+		ChirpIds: chirpIds,
+		UserIds:  userIds})
+
+	if err != nil {
+		return 0, err
+	}
+
+	for _, release := range []func(context.Context, []uuid.UUID) error{
+		queries.ReleaseUserLikes,
+		queries.ReleaseUserPollVotes,
+	} {
+		if err := release(ctx, userIds); err != nil {
+			return 0, err
+		}
+	}
+
+	followed, err := queries.ReleaseUserFollows(ctx, userIds)
+	if err != nil {
+		return 0, err
+	}
+	for _, f := range followed {
+		err = p.fanout.FollowerCountChanged(ctx, queries, f.ID, f.FollowerCount, -f.Follows)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if err := queries.HardDeleteChirps(ctx, chirpIds); err != nil {
+		return 0, err
+	}
+
+	if err := queries.HardDeleteUsers(ctx, userIds); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	p.fanout.Wake()
+
+	media.DeleteBlobs(ctx, p.blobs, mediaDb)
+
+	return max(len(userIds), len(chirpIds)), nil
+}
+
+// PurgeUnattached removes one batch of uploads made more than
+// UnattachedWindow before now that no chirp, held or published, points at.
+// It returns how many were removed.
+func (p *Purger) PurgeUnattached(ctx context.Context, now time.Time) (int, error) {
+	mediaDb, err := p.queries.PurgeUnattachedMedia(ctx, database.PurgeUnattachedMediaParams{
+		CreatedBefore: now.Add(-UnattachedWindow),
+		RowLimit:      BatchSize})
+
+	if err != nil {
+		return 0, err
+	}
+
+	media.DeleteBlobs(ctx, p.blobs, mediaDb)
+
+	return len(mediaDb), nil
+}
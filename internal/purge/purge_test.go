@@ -0,0 +1,177 @@
+package purge
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/shahanmmiah/Chirpy/internal/database"
+	"github.com/shahanmmiah/Chirpy/internal/fanout"
+	"github.com/shahanmmiah/Chirpy/internal/media"
+)
+
+// TestPurgeExpired purges a user whose likes, follow and poll vote are
+// counted on rows that survive them, while a second expired user is held by
+// another transaction as if another server had claimed it. It needs a
+// migrated database in CHIRPY_TEST_DB_URL.
+func TestPurgeExpired(t *testing.T) {
+	dbURL := os.Getenv("CHIRPY_TEST_DB_URL")
+	if dbURL == "" {
+		t.Skip("CHIRPY_TEST_DB_URL not set")
+	}
+
+	db, err := sql.Open("postgres", dbURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	ctx := context.Background()
+	now := time.Now()
+	window := time.Hour
+	expired := now.Add(-2 * window)
+
+	gone, claimed, keeper, followee := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	kept, deleted := uuid.New(), uuid.New()
+	poll, option := uuid.New(), uuid.New()
+
+	t.Cleanup(func() {
+		db.ExecContext(ctx, `DELETE FROM users WHERE id = ANY($1::UUID[])`, pq.Array([]uuid.UUID{gone, claimed, keeper, followee}))
+	})
+
+	dir := t.TempDir()
+	blobs, err := media.NewLocalStore(dir, "/app/media/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := []string{}
+	for _, owner := range []uuid.UUID{gone, deleted} {
+		for _, suffix := range []string{".png", "-thumb.png"} {
+			key := "purge-test-" + owner.String() + suffix
+			if err := blobs.Put(ctx, key, strings.NewReader("blob")); err != nil {
+				t.Fatal(err)
+			}
+			keys = append(keys, key)
+		}
+	}
+
+	seed := []struct {
+		query string
+		args  []any
+	}{
+		{`INSERT INTO users(id, created_at, updated_at, email, hashed_password, deleted_at)
+			VALUES ($1, NOW(), NOW(), $2, 'unset', $3)`, []any{gone, "purge-test-" + gone.String(), expired}},
+		{`INSERT INTO users(id, created_at, updated_at, email, hashed_password, deleted_at)
+			VALUES ($1, NOW(), NOW(), $2, 'unset', $3)`, []any{claimed, "purge-test-" + claimed.String(), expired}},
+		{`INSERT INTO users(id, created_at, updated_at, email, hashed_password)
+			VALUES ($1, NOW(), NOW(), $2, 'unset')`, []any{keeper, "purge-test-" + keeper.String()}},
+		{`INSERT INTO users(id, created_at, updated_at, email, hashed_password, follower_count)
+			VALUES ($1, NOW(), NOW(), $2, 'unset', 2)`, []any{followee, "purge-test-" + followee.String()}},
+
+		// a chirp that stays, liked and voted on by both the purged user
+		// and the keeper
+		{`INSERT INTO chirps(id, created_at, updated_at, body, user_id, like_count)
+			VALUES ($1, NOW(), NOW(), 'kept', $2, 2)`, []any{kept, keeper}},
+		{`INSERT INTO chirp_likes(chirp_id, user_id, created_at)
+			VALUES ($1, $2, NOW()), ($1, $3, NOW())`, []any{kept, gone, keeper}},
+		{`INSERT INTO polls(id, chirp_id, closes_at, created_at)
+			VALUES ($1, $2, NOW() + INTERVAL '1 day', NOW())`, []any{poll, kept}},
+		{`INSERT INTO poll_options(id, poll_id, position, body, vote_count)
+			VALUES ($1, $2, 0, 'yes', 2)`, []any{option, poll}},
+		{`INSERT INTO poll_votes(poll_id, user_id, option_id, created_at)
+			VALUES ($1, $2, $3, NOW()), ($1, $4, $3, NOW())`, []any{poll, gone, option, keeper}},
+		{`INSERT INTO follows(follower_id, followee_id, created_at)
+			VALUES ($1, $3, NOW()), ($2, $3, NOW())`, []any{gone, keeper, followee}},
+
+		// an expired chirp of the keeper's, and media of both
+		{`INSERT INTO chirps(id, created_at, updated_at, body, user_id, deleted_at)
+			VALUES ($1, NOW(), NOW(), 'deleted', $2, $3)`, []any{deleted, keeper, expired}},
+		{`INSERT INTO media_attachments(id, user_id, chirp_id, created_at, content_type, size_bytes, width, height, blob_key, thumb_key)
+			VALUES (gen_random_uuid(), $1, $2, NOW(), 'image/png', 4, 1, 1, $3, $4)`, []any{keeper, deleted, keys[2], keys[3]}},
+		{`INSERT INTO media_attachments(id, user_id, created_at, content_type, size_bytes, width, height, blob_key, thumb_key)
+			VALUES (gen_random_uuid(), $1, NOW(), 'image/png', 4, 1, 1, $2, $3)`, []any{gone, keys[0], keys[1]}},
+	}
+	for _, s := range seed {
+		if _, err := db.ExecContext(ctx, s.query, s.args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// another server is part way through purging this user
+	lock, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lock.Rollback()
+	if _, err := lock.ExecContext(ctx, `SELECT id FROM users WHERE id = $1 FOR UPDATE`, claimed); err != nil {
+		t.Fatal(err)
+	}
+
+	queries := database.New(db)
+	p := New(db, queries, blobs, fanout.NewWorker(db, queries, fanout.DefaultLimit, fanout.DefaultInterval), window, DefaultInterval)
+
+	if _, err := p.PurgeExpired(ctx, now); err != nil {
+		t.Fatal(err)
+	}
+
+	exists := func(query string, id uuid.UUID) bool {
+		var found bool
+		if err := db.QueryRowContext(ctx, `SELECT EXISTS (`+query+`)`, id).Scan(&found); err != nil {
+			t.Fatal(err)
+		}
+		return found
+	}
+	if exists(`SELECT 1 FROM users WHERE id = $1`, gone) {
+		t.Error("expired user was not purged")
+	}
+	if exists(`SELECT 1 FROM chirps WHERE id = $1`, deleted) {
+		t.Error("expired chirp was not purged")
+	}
+	if !exists(`SELECT 1 FROM users WHERE id = $1`, claimed) {
+		t.Error("user locked by another transaction was purged")
+	}
+
+	counters := []struct {
+		name  string
+		query string
+		id    uuid.UUID
+	}{
+		{"like_count", `SELECT like_count FROM chirps WHERE id = $1`, kept},
+		{"follower_count", `SELECT follower_count FROM users WHERE id = $1`, followee},
+		{"vote_count", `SELECT vote_count FROM poll_options WHERE id = $1`, option},
+	}
+	for _, c := range counters {
+		var n int
+		if err := db.QueryRowContext(ctx, c.query, c.id).Scan(&n); err != nil {
+			t.Fatal(err)
+		}
+		if n != 1 {
+			t.Errorf("%s = %d after purge, expected 1", c.name, n)
+		}
+	}
+
+	for _, key := range keys {
+		_, err := os.Stat(filepath.Join(dir, key))
+		if !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("blob %v still stored (%v)", key, err)
+		}
+	}
+
+	// once the other transaction lets go, the next run gets it
+	if err := lock.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.PurgeExpired(ctx, now); err != nil {
+		t.Fatal(err)
+	}
+	if exists(`SELECT 1 FROM users WHERE id = $1`, claimed) {
+		t.Error("expired user was not purged once it was free")
+	}
+}
@@ -0,0 +1,129 @@
+package search
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/google/uuid"
+)
+
+// Cursor marks the last result of a page in (rank, id) order.
+type Cursor struct {
+	Rank float32
+	ID   uuid.UUID
+}
+
+func (c Cursor) Encode() string {
+	raw := fmt.Sprintf("%s|%s", strconv.FormatFloat(float64(c.Rank), 'g', -1, 32), c.ID.String())
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func DecodeCursor(token string) (Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return Cursor{}, fmt.Errorf("invalid cursor")
+	}
+
+	rank, id, found := strings.Cut(string(raw), "|")
+	if !found {
+		return Cursor{}, fmt.Errorf("invalid cursor")
+	}
+
+	parsedRank, err := strconv.ParseFloat(rank, 32)
+	if err != nil {
+		return Cursor{}, fmt.Errorf("invalid cursor")
+	}
+
+	parsedId, err := uuid.Parse(id)
+	if err != nil {
+		return Cursor{}, fmt.Errorf("invalid cursor")
+	}
+
+	return Cursor{Rank: float32(parsedRank), ID: parsedId}, nil
+}
+
+func cleanTerm(term string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, term)
+}
+
+// ToTSQuery turns a user search into to_tsquery syntax. Words are ANDed,
+// "quoted phrases" must appear in order, a trailing * makes a prefix match,
+// a leading - excludes a word and OR between terms makes either match.
+// Everything else is stripped so the result is always a valid tsquery.
+func ToTSQuery(input string) (string, error) {
+	terms := []string{}
+	pendingOr := false
+
+	add := func(term string) {
+		if len(terms) > 0 {
+			if pendingOr {
+				terms = append(terms, "|")
+			} else {
+				terms = append(terms, "&")
+			}
+		}
+		terms = append(terms, term)
+		pendingOr = false
+	}
+
+	rest := input
+	for {
+		rest = strings.TrimSpace(rest)
+		if rest == "" {
+			break
+		}
+
+		if phrase, found := strings.CutPrefix(rest, `"`); found {
+			var after string
+			phrase, after, _ = strings.Cut(phrase, `"`)
+			rest = after
+
+			words := []string{}
+			for _, word := range strings.Fields(phrase) {
+				if word = cleanTerm(word); word != "" {
+					words = append(words, word)
+				}
+			}
+			if len(words) > 0 {
+				add("(" + strings.Join(words, " <-> ") + ")")
+			}
+			continue
+		}
+
+		word, after, _ := strings.Cut(rest, " ")
+		rest = after
+
+		if word == "OR" {
+			pendingOr = len(terms) > 0
+			continue
+		}
+
+		negate := strings.HasPrefix(word, "-")
+		prefix := strings.HasSuffix(word, "*")
+
+		term := cleanTerm(word)
+		if term == "" {
+			continue
+		}
+		if prefix {
+			term += ":*"
+		}
+		if negate {
+			term = "!" + term
+		}
+		add(term)
+	}
+
+	if len(terms) == 0 {
+		return "", fmt.Errorf("search query has no searchable words")
+	}
+	return strings.Join(terms, " "), nil
+}
@@ -0,0 +1,78 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestToTSQuery(t *testing.T) {
+	cases := []struct {
+		InputQuery    string
+		ExpectedQuery string
+		ExpectErr     bool
+	}{
+		{
+			InputQuery:    "boots",
+			ExpectedQuery: "boots"},
+		{
+			InputQuery:    "Learn  Go",
+			ExpectedQuery: "learn & go"},
+		{
+			InputQuery:    `"bear grylls" survival`,
+			ExpectedQuery: "(bear <-> grylls) & survival"},
+		{
+			InputQuery:    "prog*",
+			ExpectedQuery: "prog:*"},
+		{
+			InputQuery:    "cats OR dogs -birds",
+			ExpectedQuery: "cats | dogs & !birds"},
+		{
+			InputQuery:    "it's (fine) & ok!",
+			ExpectedQuery: "its & fine & ok"},
+		{
+			InputQuery: "  & | ! ",
+			ExpectErr:  true},
+		{
+			InputQuery: `""`,
+			ExpectErr:  true},
+	}
+	for _, c := range cases {
+
+		actual, err := ToTSQuery(c.InputQuery)
+
+		if c.ExpectErr {
+			if err == nil {
+				t.Errorf("error should not be nil for query '%s'", c.InputQuery)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("error building query '%s': %s", c.InputQuery, err.Error())
+		}
+
+		if actual != c.ExpectedQuery {
+			t.Errorf("error query is '%s' should be '%s'", actual, c.ExpectedQuery)
+		}
+
+	}
+}
+
+func TestCursorEncodeDecode(t *testing.T) {
+	cursor := Cursor{Rank: 0.0607927, ID: uuid.New()}
+
+	actual, err := DecodeCursor(cursor.Encode())
+	if err != nil {
+		t.Errorf("error decoding cursor: %s", err.Error())
+	}
+
+	if actual != cursor {
+		t.Errorf("error decoded cursor %v differs from input %v", actual, cursor)
+	}
+
+	_, err = DecodeCursor("bm90LWEtY3Vyc29y")
+	if err == nil {
+		t.Error("error should not be nil for an invalid cursor")
+	}
+}
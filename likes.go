@@ -0,0 +1,99 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shahanmmiah/Chirpy/internal/database"
+)
+
+func (a *ApiConfig) MiddlewareLikeChirp() http.Handler {
+	return a.likeChirpHandler(func(queries *database.Queries, req *http.Request, chirpId, userId uuid.UUID) (int32, error) {
+		added, err := queries.AddChirpLike(req.Context(), database.AddChirpLikeParams{
+			ChirpID:   chirpId,
+			UserID:    userId,
+			CreatedAt: time.Now()})
+
+		return int32(added), err
+	})
+}
+
+func (a *ApiConfig) MiddlewareUnlikeChirp() http.Handler {
+	return a.likeChirpHandler(func(queries *database.Queries, req *http.Request, chirpId, userId uuid.UUID) (int32, error) {
+		removed, err := queries.RemoveChirpLike(req.Context(), database.RemoveChirpLikeParams{
+			ChirpID: chirpId,
+			UserID:  userId})
+
+		return -int32(removed), err
+	})
+}
+
+// likeChirpHandler runs change, which returns how much the like count moved,
+// and applies that to the chirp's counter in the same transaction. Repeating
+// a like or unlike moves it by zero, so both endpoints are idempotent.
+func (a *ApiConfig) likeChirpHandler(change func(*database.Queries, *http.Request, uuid.UUID, uuid.UUID) (int32, error)) http.Handler {
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		userId, err := a.AuthenticatedUser(req)
+		if err != nil {
+			ErrorJsonResp(resp, err, UNAUTHORIZED)
+			return
+		}
+
+		chirpId, err := uuid.Parse(req.PathValue("chirpID"))
+		if err != nil {
+			ErrorJsonResp(resp, fmt.Errorf("invalid chirp id: %v", err), FAILEDCODE)
+			return
+		}
+
+		tx, err := a.Db.BeginTx(req.Context(), nil)
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+		defer tx.Rollback()
+
+		queries := a.DbQueries.WithTx(tx)
+
+		chirpDb, err := queries.GetVisibleChirp(req.Context(), database.GetVisibleChirpParams{
+			ID:       chirpId,
+			ViewerID: uuid.NullUUID{UUID: userId, Valid: true}})
+
+		if errors.Is(err, sql.ErrNoRows) {
+			ErrorJsonResp(resp, fmt.Errorf("chirp %v not found", chirpId), NOTFOUNDCODE)
+			return
+		}
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		delta, err := change(queries, req, chirpId, userId)
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		if delta != 0 {
+			chirpDb, err = queries.AdjustChirpLikeCount(req.Context(), database.AdjustChirpLikeCountParams{
+				Delta: delta,
+				ID:    chirpId})
+
+			if err != nil {
+				ErrorJsonResp(resp, err, FAILEDCODE)
+				return
+			}
+		}
+
+		err = tx.Commit()
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		a.ChirpResp(resp, req, chirpDb, OKCODE)
+	})
+}
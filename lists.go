@@ -0,0 +1,432 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/shahanmmiah/Chirpy/internal/database"
+	"github.com/shahanmmiah/Chirpy/internal/pagination"
+)
+
+// MAXLISTNAME is the longest list name allowed, in characters.
+const MAXLISTNAME = 50
+
+// ListJson is a named list of users. Private lists are only ever shown to
+// their owner.
+type ListJson struct {
+	ID        uuid.UUID `json:"id"`
+	OwnerID   uuid.UUID `json:"owner_id"`
+	Name      string    `json:"name"`
+	Private   bool      `json:"private"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func ListDbToJson(listDb database.UserList) ListJson {
+	return ListJson{
+		ID:        listDb.ID,
+		OwnerID:   listDb.OwnerID,
+		Name:      listDb.Name,
+		Private:   listDb.IsPrivate,
+		CreatedAt: listDb.CreatedAt,
+		UpdatedAt: listDb.UpdatedAt}
+}
+
+// ParseListName trims a list name and checks it is not empty or too long.
+func ParseListName(raw string) (string, error) {
+	name := strings.TrimSpace(raw)
+	if name == "" || utf8.RuneCountInString(name) > MAXLISTNAME {
+		return "", fmt.Errorf("list name must be 1-%d characters", MAXLISTNAME)
+	}
+	return name, nil
+}
+
+// ListFromPath loads the list named by the {listID} path value if the caller
+// can see it, writing the error response itself when that fails. A private
+// list is reported as not found to anyone but its owner.
+func (a *ApiConfig) ListFromPath(resp http.ResponseWriter, req *http.Request) (database.UserList, bool) {
+	id, err := uuid.Parse(req.PathValue("listID"))
+	if err != nil {
+		ErrorJsonResp(resp, fmt.Errorf("invalid list id: %v", err), FAILEDCODE)
+		return database.UserList{}, false
+	}
+
+	listDb, err := a.DbQueries.GetVisibleList(req.Context(), database.GetVisibleListParams{
+		ID:       id,
+		ViewerID: a.Viewer(req)})
+
+	if errors.Is(err, sql.ErrNoRows) {
+		ErrorJsonResp(resp, fmt.Errorf("list %v not found", id), NOTFOUNDCODE)
+		return database.UserList{}, false
+	}
+	if err != nil {
+		ErrorJsonResp(resp, err, FAILEDCODE)
+		return database.UserList{}, false
+	}
+
+	return listDb, true
+}
+
+// listBody reads the name and privacy of a list from a create or update.
+func listBody(req *http.Request) (string, bool, error) {
+	resData := struct {
+		Name    string `json:"name"`
+		Private bool   `json:"private"`
+	}{}
+
+	reqData, err := io.ReadAll(req.Body)
+	if err != nil {
+		return "", false, err
+	}
+
+	err = json.Unmarshal(reqData, &resData)
+	if err != nil {
+		return "", false, err
+	}
+
+	name, err := ParseListName(resData.Name)
+	return name, resData.Private, err
+}
+
+func ListResp(resp http.ResponseWriter, listDb database.UserList, code int) {
+	jsonData, err := json.Marshal(ListDbToJson(listDb))
+	if err != nil {
+		ErrorJsonResp(resp, err, FAILEDCODE)
+		return
+	}
+
+	resp.Header().Set("Content-Type", "application/json")
+	resp.WriteHeader(code)
+	resp.Write(jsonData)
+}
+
+func (a *ApiConfig) MiddlewareCreateList() http.Handler {
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		userId, err := a.AuthenticatedUser(req)
+		if err != nil {
+			ErrorJsonResp(resp, err, UNAUTHORIZED)
+			return
+		}
+
+		name, private, err := listBody(req)
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		now := time.Now()
+		listDb, err := a.DbQueries.CreateList(req.Context(), database.CreateListParams{
+			ID:        uuid.New(),
+			OwnerID:   userId,
+			Name:      name,
+			IsPrivate: private,
+			CreatedAt: now,
+			UpdatedAt: now})
+
+		if IsUniqueViolation(err) {
+			ErrorJsonResp(resp, fmt.Errorf("you already have a list called %q", name), CONFLICTCODE)
+			return
+		}
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		ListResp(resp, listDb, NEWCODE)
+	})
+}
+
+func (a *ApiConfig) MiddlewareGetList() http.Handler {
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		listDb, ok := a.ListFromPath(resp, req)
+		if !ok {
+			return
+		}
+
+		ListResp(resp, listDb, OKCODE)
+	})
+}
+
+// MiddlewareUpdateList renames a list or changes its privacy. Both fields are
+// replaced, so an update that leaves out private makes the list public.
+func (a *ApiConfig) MiddlewareUpdateList() http.Handler {
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		userId, err := a.AuthenticatedUser(req)
+		if err != nil {
+			ErrorJsonResp(resp, err, UNAUTHORIZED)
+			return
+		}
+
+		id, err := uuid.Parse(req.PathValue("listID"))
+		if err != nil {
+			ErrorJsonResp(resp, fmt.Errorf("invalid list id: %v", err), FAILEDCODE)
+			return
+		}
+
+		name, private, err := listBody(req)
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		listDb, err := a.DbQueries.UpdateList(req.Context(), database.UpdateListParams{
+			Name:      name,
+			IsPrivate: private,
+			UpdatedAt: time.Now(),
+			ID:        id,
+			OwnerID:   userId})
+
+		if errors.Is(err, sql.ErrNoRows) {
+			ErrorJsonResp(resp, fmt.Errorf("list %v not found", id), NOTFOUNDCODE)
+			return
+		}
+		if IsUniqueViolation(err) {
+			ErrorJsonResp(resp, fmt.Errorf("you already have a list called %q", name), CONFLICTCODE)
+			return
+		}
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		ListResp(resp, listDb, OKCODE)
+	})
+}
+
+func (a *ApiConfig) MiddlewareDeleteList() http.Handler {
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		userId, err := a.AuthenticatedUser(req)
+		if err != nil {
+			ErrorJsonResp(resp, err, UNAUTHORIZED)
+			return
+		}
+
+		id, err := uuid.Parse(req.PathValue("listID"))
+		if err != nil {
+			ErrorJsonResp(resp, fmt.Errorf("invalid list id: %v", err), FAILEDCODE)
+			return
+		}
+
+		deleted, err := a.DbQueries.DeleteList(req.Context(), database.DeleteListParams{ID: id, OwnerID: userId})
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+		if deleted == 0 {
+			ErrorJsonResp(resp, fmt.Errorf("list %v not found", id), NOTFOUNDCODE)
+			return
+		}
+
+		resp.WriteHeader(NOCONTENTCODE)
+	})
+}
+
+// MiddlewareAddListMember adds the user in the path to one of the caller's
+// lists. As with following, users who have blocked each other cannot be
+// added.
+func (a *ApiConfig) MiddlewareAddListMember() http.Handler {
+	return a.listMemberChangeHandler(func(req *http.Request, listDb database.UserList, userDb database.User) (int, error) {
+		blocked, err := a.DbQueries.IsBlockedBetween(req.Context(), database.IsBlockedBetweenParams{
+			UserA: listDb.OwnerID,
+			UserB: userDb.ID})
+
+		if err != nil {
+			return FAILEDCODE, err
+		}
+		if blocked {
+			return FORBIDDENCODE, fmt.Errorf("cannot add user %v to a list", userDb.ID)
+		}
+
+		_, err = a.DbQueries.AddListMember(req.Context(), database.AddListMemberParams{
+			ListID:    listDb.ID,
+			UserID:    userDb.ID,
+			CreatedAt: time.Now()})
+
+		return FAILEDCODE, err
+	})
+}
+
+func (a *ApiConfig) MiddlewareRemoveListMember() http.Handler {
+	return a.listMemberChangeHandler(func(req *http.Request, listDb database.UserList, userDb database.User) (int, error) {
+		_, err := a.DbQueries.RemoveListMember(req.Context(), database.RemoveListMemberParams{
+			ListID: listDb.ID,
+			UserID: userDb.ID})
+
+		return FAILEDCODE, err
+	})
+}
+
+// listMemberChangeHandler applies change to the list and user in the path
+// once it has checked the caller owns the list. change returns the status to
+// fail with alongside any error. Repeating a change is not an error.
+func (a *ApiConfig) listMemberChangeHandler(change func(*http.Request, database.UserList, database.User) (int, error)) http.Handler {
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		userId, err := a.AuthenticatedUser(req)
+		if err != nil {
+			ErrorJsonResp(resp, err, UNAUTHORIZED)
+			return
+		}
+
+		listDb, ok := a.ListFromPath(resp, req)
+		if !ok {
+			return
+		}
+
+		if listDb.OwnerID != userId {
+			ErrorJsonResp(resp, fmt.Errorf("only the owner can change this list"), FORBIDDENCODE)
+			return
+		}
+
+		userDb, ok := a.UserFromPath(resp, req)
+		if !ok {
+			return
+		}
+
+		code, err := change(req, listDb, userDb)
+		if err != nil {
+			ErrorJsonResp(resp, err, code)
+			return
+		}
+
+		resp.WriteHeader(NOCONTENTCODE)
+	})
+}
+
+// MiddlewareGetListMembers pages through the members of a list, most
+// recently added first.
+func (a *ApiConfig) MiddlewareGetListMembers() http.Handler {
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		listDb, ok := a.ListFromPath(resp, req)
+		if !ok {
+			return
+		}
+
+		page, err := pagination.FromQuery(req.URL.Query())
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		rows, err := a.DbQueries.ListListMembers(req.Context(), database.ListListMembersParams{
+			ListID:          listDb.ID,
+			CursorCreatedAt: page.CursorCreatedAt(),
+			CursorID:        page.CursorID(),
+			RowLimit:        page.FetchLimit()})
+
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		members := []RelationJson{}
+		for _, r := range rows {
+			members = append(members, RelationJson{UserID: r.User.ID, Username: r.User.Username.String, CreatedAt: r.AddedAt})
+		}
+
+		if len(members) > int(page.Limit) {
+			members = members[:page.Limit]
+			last := members[len(members)-1]
+			resp.Header().Set("Link", pagination.NextLink(req.URL, pagination.Cursor{CreatedAt: last.CreatedAt, ID: last.UserID}.Encode()))
+		}
+
+		jsonData, err := json.Marshal(members)
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		resp.Header().Set("Content-Type", "application/json")
+		resp.WriteHeader(OKCODE)
+		resp.Write(jsonData)
+	})
+}
+
+// MiddlewareGetListChirps is the timeline of a list: chirps by its members,
+// newest first. As on the home timeline, members blocked either way or muted
+// by the caller are left out, and so are deleted accounts.
+func (a *ApiConfig) MiddlewareGetListChirps() http.Handler {
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		listDb, ok := a.ListFromPath(resp, req)
+		if !ok {
+			return
+		}
+
+		page, err := pagination.FromQuery(req.URL.Query())
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		chirpsDb, err := a.DbQueries.ListListChirps(req.Context(), database.ListListChirpsParams{
+			ListID:          listDb.ID,
+			ViewerID:        a.Viewer(req),
+			CursorCreatedAt: page.CursorCreatedAt(),
+			CursorID:        page.CursorID(),
+			RowLimit:        page.FetchLimit()})
+
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		a.WriteChirpPage(resp, req, page, chirpsDb)
+	})
+}
+
+// MiddlewareGetUserLists pages through a user's lists, newest first. Their
+// private lists are included only when they are the caller.
+func (a *ApiConfig) MiddlewareGetUserLists() http.Handler {
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		userDb, ok := a.VisibleUserFromPath(resp, req)
+		if !ok {
+			return
+		}
+
+		page, err := pagination.FromQuery(req.URL.Query())
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		listsDb, err := a.DbQueries.ListUserLists(req.Context(), database.ListUserListsParams{
+			OwnerID:         userDb.ID,
+			ViewerID:        a.Viewer(req),
+			CursorCreatedAt: page.CursorCreatedAt(),
+			CursorID:        page.CursorID(),
+			RowLimit:        page.FetchLimit()})
+
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		if len(listsDb) > int(page.Limit) {
+			listsDb = listsDb[:page.Limit]
+			last := listsDb[len(listsDb)-1]
+			resp.Header().Set("Link", pagination.NextLink(req.URL, pagination.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}.Encode()))
+		}
+
+		listsJson := []ListJson{}
+		for _, l := range listsDb {
+			listsJson = append(listsJson, ListDbToJson(l))
+		}
+
+		jsonData, err := json.Marshal(listsJson)
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		resp.Header().Set("Content-Type", "application/json")
+		resp.WriteHeader(OKCODE)
+		resp.Write(jsonData)
+	})
+}
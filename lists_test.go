@@ -0,0 +1,32 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseListName(t *testing.T) {
+	cases := []struct {
+		Input        string
+		ExpectedName string
+		ExpectedErr  bool
+	}{
+		{Input: "Friends", ExpectedName: "Friends"},
+		{Input: "  Go people  ", ExpectedName: "Go people"},
+		{Input: "Ünïcödé " + strings.Repeat("é", MAXLISTNAME-8), ExpectedName: "Ünïcödé " + strings.Repeat("é", MAXLISTNAME-8)},
+		{Input: strings.Repeat("a", MAXLISTNAME+1), ExpectedErr: true},
+		{Input: "   ", ExpectedErr: true},
+		{Input: "", ExpectedErr: true},
+	}
+
+	for _, c := range cases {
+		name, err := ParseListName(c.Input)
+		if (err != nil) != c.ExpectedErr {
+			t.Errorf("ParseListName(%q) error = %v, expected error: %v", c.Input, err, c.ExpectedErr)
+			continue
+		}
+		if name != c.ExpectedName {
+			t.Errorf("ParseListName(%q) = %q, expected %q", c.Input, name, c.ExpectedName)
+		}
+	}
+}
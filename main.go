@@ -1,22 +1,31 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
-	"slices"
 	"strings"
 	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/joho/godotenv"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 	"github.com/shahanmmiah/Chirpy/internal/auth"
+	"github.com/shahanmmiah/Chirpy/internal/chirptext"
 	"github.com/shahanmmiah/Chirpy/internal/database"
+	"github.com/shahanmmiah/Chirpy/internal/fanout"
+	"github.com/shahanmmiah/Chirpy/internal/media"
+	"github.com/shahanmmiah/Chirpy/internal/moderation"
+	"github.com/shahanmmiah/Chirpy/internal/pagination"
+	"github.com/shahanmmiah/Chirpy/internal/preview"
+	"github.com/shahanmmiah/Chirpy/internal/publisher"
+	"github.com/shahanmmiah/Chirpy/internal/purge"
 )
 
 type Handler struct {
@@ -27,23 +36,114 @@ type Handler struct {
 type Handlers map[string]map[string]Handler
 
 type ChirpJson struct {
-	ID        uuid.UUID `json:"id"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	Body      string    `json:"body"`
-	UserID    uuid.UUID `json:"user_id"`
+	ID         uuid.UUID     `json:"id"`
+	CreatedAt  time.Time     `json:"created_at"`
+	UpdatedAt  time.Time     `json:"updated_at"`
+	Body       string        `json:"body"`
+	UserID     uuid.UUID     `json:"user_id"`
+	InReplyTo  *uuid.UUID    `json:"in_reply_to"`
+	ReplyCount int32         `json:"reply_count"`
+	LikeCount  int32         `json:"like_count"`
+	LikedByMe  *bool         `json:"liked_by_me,omitempty"`
+	Bookmarked *bool         `json:"bookmarked,omitempty"`
+	ChirpKind  string        `json:"chirp_kind"`
+	RefChirpID *uuid.UUID    `json:"ref_chirp_id"`
+	RefChirp   *ChirpJson    `json:"ref_chirp,omitempty"`
+	RefDeleted bool          `json:"ref_deleted,omitempty"`
+	Tags       []string      `json:"tags"`
+	Mentions   []MentionJson `json:"mentions"`
+	Media      []MediaJson   `json:"media"`
+	Preview    *PreviewJson  `json:"preview,omitempty"`
+	Poll       *PollJson     `json:"poll,omitempty"`
+	Edited     bool          `json:"edited"`
+	Deleted    bool          `json:"deleted,omitempty"`
+	Status     string        `json:"status"`
+	PublishAt  *time.Time    `json:"publish_at,omitempty"`
+}
+
+// PreviewJson is the card for the first link in a chirp. It is filled in by a
+// background fetch, so a chirp has none until shortly after it is posted.
+type PreviewJson struct {
+	URL         string `json:"url"`
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	ImageURL    string `json:"image_url,omitempty"`
+	SiteName    string `json:"site_name,omitempty"`
+}
+
+// MentionJson locates an @handle in the chirp body. Offset and length count
+// Unicode code points and include the '@'.
+type MentionJson struct {
+	Offset   int32     `json:"offset"`
+	Length   int32     `json:"length"`
+	UserID   uuid.UUID `json:"user_id"`
+	Username string    `json:"username,omitempty"`
 }
 
 type UserJson struct {
 	Password string `json:"password"`
 	Email    string `json:"email"`
+	Username string `json:"username"`
 }
 
 type UserDbJson struct {
-	ID         uuid.UUID `json:"id"`
-	CreatedAt  time.Time `json:"created_at"`
-	UpdateddAt time.Time `json:"updated_at"`
-	Email      string    `json:"email"`
+	ID          uuid.UUID `json:"id"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdateddAt  time.Time `json:"updated_at"`
+	Email       string    `json:"email"`
+	Username    string    `json:"username,omitempty"`
+	IsChirpyRed bool      `json:"is_chirpy_red"`
+}
+
+type LoginJson struct {
+	UserDbJson
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+type TokenJson struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+func UserDbToJson(userDb database.User) UserDbJson {
+	return UserDbJson{
+		ID:          userDb.ID,
+		CreatedAt:   userDb.CreatedAt,
+		UpdateddAt:  userDb.UpdatedAt,
+		Email:       userDb.Email,
+		Username:    userDb.Username.String,
+		IsChirpyRed: userDb.IsChirpyRed}
+}
+
+// ParseUsername validates an optional handle from a request body.
+func ParseUsername(raw string) (sql.NullString, error) {
+	if raw == "" {
+		return sql.NullString{}, nil
+	}
+
+	if !chirptext.IsValidUsername(raw) {
+		return sql.NullString{}, fmt.Errorf("username must be 1-%d letters, digits or underscores", chirptext.MaxUsernameLen)
+	}
+	return sql.NullString{String: raw, Valid: true}, nil
+}
+
+// ParseOptionalUUID treats an empty string as "no value" rather than an error.
+func ParseOptionalUUID(raw string) (uuid.NullUUID, error) {
+	if raw == "" {
+		return uuid.NullUUID{}, nil
+	}
+
+	id, err := uuid.Parse(raw)
+	if err != nil {
+		return uuid.NullUUID{}, err
+	}
+	return uuid.NullUUID{UUID: id, Valid: true}, nil
+}
+
+func IsUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
 }
 
 func RedinisHandler() http.Handler {
@@ -60,7 +160,70 @@ func RedinisHandler() http.Handler {
 
 type ApiConfig struct {
 	fileserverHits atomic.Int32
+	Db             *sql.DB
 	DbQueries      *database.Queries
+	JwtSecret      string
+	PolkaKey       string
+	AdminKey       string
+	Moderator      *moderation.Filter
+	ModerationFile string
+	Fanout         *fanout.Worker
+	Media          media.BlobStore
+	Previews       *preview.Worker
+}
+
+// AuthenticatedUser returns the user the caller's access token was issued
+// to. Tokens outlive a deleted account, so the user must also still be live.
+func (a *ApiConfig) AuthenticatedUser(req *http.Request) (uuid.UUID, error) {
+	token, err := auth.GetBearerToken(req.Header)
+	if err != nil {
+		return uuid.UUID{}, err
+	}
+
+	userId, err := auth.ValidateJWT(token, a.JwtSecret)
+	if err != nil {
+		return uuid.UUID{}, err
+	}
+
+	live, err := a.DbQueries.UserIsLive(req.Context(), userId)
+	if err != nil {
+		return uuid.UUID{}, err
+	}
+	if !live {
+		return uuid.UUID{}, fmt.Errorf("unknown user %v", userId)
+	}
+	return userId, nil
+}
+
+// Viewer identifies the caller of a public read. A missing or invalid token
+// makes the caller anonymous rather than failing the request.
+func (a *ApiConfig) Viewer(req *http.Request) uuid.NullUUID {
+	userId, err := a.AuthenticatedUser(req)
+	if err != nil {
+		return uuid.NullUUID{}
+	}
+	return uuid.NullUUID{UUID: userId, Valid: true}
+}
+
+func (a *ApiConfig) IssueRefreshToken(ctx context.Context, userId, familyId uuid.UUID) (string, error) {
+	refreshToken, err := auth.MakeRefreshToken()
+	if err != nil {
+		return "", err
+	}
+
+	_, err = a.DbQueries.CreateRefreshToken(ctx, database.CreateRefreshTokenParams{
+		Token:     refreshToken,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		UserID:    userId,
+		FamilyID:  familyId,
+		ExpiresAt: time.Now().UTC().Add(REFRESHTOKENEXPIRE)})
+
+	if err != nil {
+		return "", err
+	}
+
+	return refreshToken, nil
 }
 
 func (a *ApiConfig) MiddlewareIncHits(handler http.Handler) http.Handler {
@@ -115,216 +278,953 @@ func ErrorJsonResp(resp http.ResponseWriter, err error, errorCode int) {
 	resp.Write(jsonData)
 }
 
-func SanatizeProfane(text string) string {
+// ValidateChirp measures chirp in user-perceived characters and reports
+// whether it fits within chripLen.
+func ValidateChirp(chirp string, chripLen, urlWeight int) (int, bool) {
+	length := chirptext.Length(chirp, urlWeight)
+	return length, length <= chripLen
+}
+
+// CheckChirpLength validates a chirp body against its author's tier limit,
+// writing the error response when it does not fit.
+func (a *ApiConfig) CheckChirpLength(resp http.ResponseWriter, req *http.Request, userId uuid.UUID, body string, limits ChirpLimits) bool {
+	userDb, err := a.DbQueries.GetUserFromId(req.Context(), userId)
+	if err != nil {
+		ErrorJsonResp(resp, fmt.Errorf("unknown user %v", userId), UNAUTHORIZED)
+		return false
+	}
+
+	limit := limits.For(userDb.IsChirpyRed)
+	length, ok := ValidateChirp(body, limit, limits.URLWeight)
+	if !ok {
+		ChirpTooLongResp(resp, length, limit)
+		return false
+	}
+	return true
+}
+
+// ModerateWithoutHold checks a body that cannot go through the held queue:
+// drafts, scheduled chirps and edits. Only the filter's censoring applies, so
+// words that would hold a new chirp are refused like rejected ones.
+func (a *ApiConfig) ModerateWithoutHold(resp http.ResponseWriter, body string) (moderation.Result, bool) {
+	moderated, err := a.CheckWithoutHold(body)
+	if err != nil {
+		ErrorJsonResp(resp, err, UNPROCESSABLECODE)
+		return moderated, false
+	}
+	return moderated, true
+}
+
+// CheckWithoutHold is ModerateWithoutHold without the response.
+func (a *ApiConfig) CheckWithoutHold(body string) (moderation.Result, error) {
+	moderated := a.Moderator.Check(body)
+	if moderated.Action == moderation.PolicyReject || moderated.Action == moderation.PolicyHold {
+		return moderated, fmt.Errorf("chirp contains words that are not allowed: %s", strings.Join(moderated.MatchedWords(), ", "))
+	}
+	return moderated, nil
+}
+
+func ChirpTooLongResp(resp http.ResponseWriter, length, limit int) {
+	errData := struct {
+		Error  string `json:"error"`
+		Length int    `json:"length"`
+		Limit  int    `json:"limit"`
+	}{
+		Error:  fmt.Sprintf("error Chirp is too long: %d characters, limit is %d", length, limit),
+		Length: length,
+		Limit:  limit}
+
+	jsonData, _ := json.Marshal(errData)
+	resp.Header().Set("Content-Type", "application/json")
+	resp.WriteHeader(FAILEDCODE)
+	resp.Write(jsonData)
+}
+
+func ChirpDbToJson(chirpDb database.Chirp) ChirpJson {
+	return ChirpJson{
+		ID:         chirpDb.ID,
+		CreatedAt:  chirpDb.CreatedAt,
+		UpdatedAt:  chirpDb.UpdatedAt,
+		Body:       chirpDb.Body,
+		UserID:     chirpDb.UserID,
+		InReplyTo:  NullUUIDToPtr(chirpDb.InReplyTo),
+		LikeCount:  chirpDb.LikeCount,
+		ChirpKind:  chirpDb.ChirpKind,
+		RefChirpID: NullUUIDToPtr(chirpDb.RefChirpID),
+		Edited:     chirpDb.EditedAt.Valid,
+		Status:     chirpDb.Status,
+		PublishAt:  NullTimeToPtr(chirpDb.PublishAt),
+		Tags:       []string{},
+		Mentions:   []MentionJson{},
+		Media:      []MediaJson{}}
+}
+
+// ChirpsToJson converts chirps for a response, loading the rows that hang off
+// each chirp with one query per kind rather than one per chirp. Per-viewer
+// fields are only filled in when viewer is set.
+func (a *ApiConfig) ChirpsToJson(ctx context.Context, viewer uuid.NullUUID, chirpsDb []database.Chirp) ([]ChirpJson, error) {
+	return a.chirpsToJson(ctx, viewer, chirpsDb, true)
+}
+
+// chirpsToJson embeds the chirps that rechirps and quotes point at when
+// embedRefs is set. The embedded chirps are converted without it, so they
+// only ever go one level deep.
+func (a *ApiConfig) chirpsToJson(ctx context.Context, viewer uuid.NullUUID, chirpsDb []database.Chirp, embedRefs bool) ([]ChirpJson, error) {
+	chirpsJson := []ChirpJson{}
+	if len(chirpsDb) == 0 {
+		return chirpsJson, nil
+	}
+
+	ids := []uuid.UUID{}
+	byId := map[uuid.UUID]*ChirpJson{}
+	for _, c := range chirpsDb {
+		chirpsJson = append(chirpsJson, ChirpDbToJson(c))
+		ids = append(ids, c.ID)
+	}
+	for i := range chirpsJson {
+		byId[chirpsJson[i].ID] = &chirpsJson[i]
+	}
+
+	tagsDb, err := a.DbQueries.GetTagsForChirps(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range tagsDb {
+		byId[t.ChirpID].Tags = append(byId[t.ChirpID].Tags, t.Name)
+	}
+
+	mentionsDb, err := a.DbQueries.GetMentionsForChirps(ctx, database.GetMentionsForChirpsParams{
+		ChirpIds: ids,
+		ViewerID: viewer})
+
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range mentionsDb {
+		byId[m.ChirpID].Mentions = append(byId[m.ChirpID].Mentions, MentionJson{
+			Offset:   m.StartOffset,
+			Length:   m.Length,
+			UserID:   m.UserID,
+			Username: m.Username.String})
+	}
+
+	mediaDb, err := a.DbQueries.ListMediaForChirps(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range mediaDb {
+		byId[m.ChirpID.UUID].Media = append(byId[m.ChirpID.UUID].Media, a.MediaDbToJson(m))
+	}
+
+	previewsDb, err := a.DbQueries.ListLinkPreviewsForChirps(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range previewsDb {
+		byId[p.ChirpID].Preview = &PreviewJson{
+			URL:         p.Url,
+			Title:       p.Title,
+			Description: p.Description,
+			ImageURL:    p.ImageUrl,
+			SiteName:    p.SiteName}
+	}
+
+	polls, err := a.pollsToJson(ctx, viewer, ids)
+	if err != nil {
+		return nil, err
+	}
+	for id, poll := range polls {
+		byId[id].Poll = poll
+	}
+
+	repliesDb, err := a.DbQueries.CountRepliesForChirps(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range repliesDb {
+		byId[r.ChirpID].ReplyCount = r.Replies
+	}
+
+	if viewer.Valid {
+		likedDb, err := a.DbQueries.GetLikedChirps(ctx, database.GetLikedChirpsParams{
+			UserID:   viewer.UUID,
+			ChirpIds: ids})
+
+		if err != nil {
+			return nil, err
+		}
+
+		liked := map[uuid.UUID]bool{}
+		for _, id := range likedDb {
+			liked[id] = true
+		}
+		for i := range chirpsJson {
+			likedByMe := liked[chirpsJson[i].ID]
+			chirpsJson[i].LikedByMe = &likedByMe
+		}
+
+		bookmarkedDb, err := a.DbQueries.GetBookmarkedChirps(ctx, database.GetBookmarkedChirpsParams{
+			UserID:   viewer.UUID,
+			ChirpIds: ids})
+
+		if err != nil {
+			return nil, err
+		}
+
+		bookmarked := map[uuid.UUID]bool{}
+		for _, id := range bookmarkedDb {
+			bookmarked[id] = true
+		}
+		for i := range chirpsJson {
+			bookmarkedByMe := bookmarked[chirpsJson[i].ID]
+			chirpsJson[i].Bookmarked = &bookmarkedByMe
+		}
+	}
+
+	if embedRefs {
+		refIds := []uuid.UUID{}
+		for _, c := range chirpsDb {
+			if c.RefChirpID.Valid {
+				refIds = append(refIds, c.RefChirpID.UUID)
+			}
+		}
+
+		refsDb := []database.Chirp{}
+		deletedRefs := map[uuid.UUID]bool{}
+		if len(refIds) > 0 {
+			refsDb, err = a.DbQueries.GetChirpsByIds(ctx, database.GetChirpsByIdsParams{
+				Ids:      refIds,
+				ViewerID: viewer})
+			if err != nil {
+				return nil, err
+			}
+
+			deletedDb, err := a.DbQueries.ListDeletedChirpIds(ctx, database.ListDeletedChirpIdsParams{
+				Ids:      refIds,
+				ViewerID: viewer})
+			if err != nil {
+				return nil, err
+			}
+			for _, id := range deletedDb {
+				deletedRefs[id] = true
+			}
+		}
+
+		refsJson, err := a.chirpsToJson(ctx, viewer, refsDb, false)
+		if err != nil {
+			return nil, err
+		}
+
+		refsById := map[uuid.UUID]*ChirpJson{}
+		for i := range refsJson {
+			refsById[refsJson[i].ID] = &refsJson[i]
+		}
+
+		for i, c := range chirpsDb {
+			if c.ChirpKind == CHIRP_ORIGINAL {
+				continue
+			}
+			// the original was purged, or is deleted but still restorable;
+			// one hidden by a block is just left out
+			if !c.RefChirpID.Valid || deletedRefs[c.RefChirpID.UUID] {
+				chirpsJson[i].RefDeleted = true
+				continue
+			}
+			chirpsJson[i].RefChirp = refsById[c.RefChirpID.UUID]
+		}
+	}
+
+	// only threads load deleted chirps, to keep the replies under them
+	for i, c := range chirpsDb {
+		if c.DeletedAt.Valid {
+			chirpsJson[i] = ChirpTombstone(c, chirpsJson[i].ReplyCount)
+		}
+	}
+
+	return chirpsJson, nil
+}
+
+func (a *ApiConfig) ChirpResp(resp http.ResponseWriter, req *http.Request, chirpDb database.Chirp, code int) {
+	chirpsJson, err := a.ChirpsToJson(req.Context(), a.Viewer(req), []database.Chirp{chirpDb})
+	if err != nil {
+		ErrorJsonResp(resp, err, FAILEDCODE)
+		return
+	}
+
+	jsonData, err := json.Marshal(chirpsJson[0])
+	if err != nil {
+		ErrorJsonResp(resp, err, FAILEDCODE)
+		return
+	}
+
+	resp.Header().Set("Content-Type", "application/json")
+	resp.WriteHeader(code)
+	resp.Write(jsonData)
+}
+
+// StoreChirpEntities saves what is parsed out of a new chirp's body. It runs
+// on the same queries (and so the same transaction) that created the chirp.
+func StoreChirpEntities(ctx context.Context, queries *database.Queries, chirpDb database.Chirp) error {
+	for _, name := range chirptext.TagNames(chirpDb.Body) {
+		tagDb, err := queries.UpsertTag(ctx, database.UpsertTagParams{
+			ID:        uuid.New(),
+			CreatedAt: time.Now(),
+			Name:      name})
+
+		if err != nil {
+			return err
+		}
+
+		err = queries.AddChirpTag(ctx, database.AddChirpTagParams{
+			ChirpID:   chirpDb.ID,
+			TagID:     tagDb.ID,
+			CreatedAt: chirpDb.CreatedAt})
+
+		if err != nil {
+			return err
+		}
+	}
+
+	mentions := chirptext.Mentions(chirpDb.Body)
+	if len(mentions) == 0 {
+		return nil
+	}
+
+	handles := []string{}
+	for _, m := range mentions {
+		handles = append(handles, strings.ToLower(m.Text))
+	}
+
+	usersDb, err := queries.GetMentionableUsers(ctx, database.GetMentionableUsersParams{
+		Usernames: handles,
+		AuthorID:  chirpDb.UserID})
+	if err != nil {
+		return err
+	}
+
+	userIds := map[string]uuid.UUID{}
+	for _, u := range usersDb {
+		userIds[strings.ToLower(u.Username.String)] = u.ID
+	}
+
+	for _, m := range mentions {
+		userId, found := userIds[strings.ToLower(m.Text)]
+		if !found {
+			continue
+		}
+
+		offset, length := chirptext.RuneOffsets(chirpDb.Body, m)
+		err = queries.AddChirpMention(ctx, database.AddChirpMentionParams{
+			ChirpID:     chirpDb.ID,
+			UserID:      userId,
+			CreatedAt:   chirpDb.CreatedAt,
+			StartOffset: int32(offset),
+			Length:      int32(length)})
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (a *ApiConfig) MiddlewareGetChirps() http.Handler {
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+
+		id, err := uuid.Parse(req.PathValue("chirpID"))
+		if err != nil {
+			ErrorJsonResp(resp, fmt.Errorf("invalid chirp id: %v", err), FAILEDCODE)
+			return
+		}
+
+		chirpDb, err := a.DbQueries.GetVisibleChirp(req.Context(), database.GetVisibleChirpParams{
+			ID:       id,
+			ViewerID: a.Viewer(req)})
+
+		if errors.Is(err, sql.ErrNoRows) {
+			ErrorJsonResp(resp, fmt.Errorf("chirp %v not found", id), NOTFOUNDCODE)
+			return
+		}
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		a.ChirpResp(resp, req, chirpDb, OKCODE)
+
+	})
+
+}
+
+func (a *ApiConfig) MiddlewareGetAllChirps() http.Handler {
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		page, err := pagination.FromQuery(req.URL.Query())
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
 
-	profaneWords := map[string]string{"kerfuffle": CENSORSTR, "sharbert": CENSORSTR, "fornax": CENSORSTR}
-	cleanStr := text
+		authorId, err := ParseOptionalUUID(req.URL.Query().Get("author_id"))
+		if err != nil {
+			ErrorJsonResp(resp, fmt.Errorf("invalid author_id: %v", err), FAILEDCODE)
+			return
+		}
 
-	for _, word := range strings.Fields(text) {
+		var chirpsDb []database.Chirp
+		switch req.URL.Query().Get("sort") {
+		case "", "asc":
+			chirpsDb, err = a.DbQueries.ListChirpsAsc(req.Context(), database.ListChirpsAscParams{
+				AuthorID:        authorId,
+				ViewerID:        a.Viewer(req),
+				CursorCreatedAt: page.CursorCreatedAt(),
+				CursorID:        page.CursorID(),
+				RowLimit:        page.FetchLimit()})
+		case "desc":
+			chirpsDb, err = a.DbQueries.ListChirpsDesc(req.Context(), database.ListChirpsDescParams{
+				AuthorID:        authorId,
+				ViewerID:        a.Viewer(req),
+				CursorCreatedAt: page.CursorCreatedAt(),
+				CursorID:        page.CursorID(),
+				RowLimit:        page.FetchLimit()})
+		default:
+			ErrorJsonResp(resp, fmt.Errorf("sort must be asc or desc"), FAILEDCODE)
+			return
+		}
 
-		if _, found := profaneWords[strings.ToLower(word)]; found {
-			cleanStr = strings.Replace(cleanStr, word, CENSORSTR, 1)
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
 		}
 
+		a.WriteChirpPage(resp, req, page, chirpsDb)
+	})
+}
+
+// WriteChirpPage drops the look-ahead row fetched with page.FetchLimit and
+// advertises the following page through a Link header when there is one.
+func (a *ApiConfig) WriteChirpPage(resp http.ResponseWriter, req *http.Request, page pagination.Page, chirpsDb []database.Chirp) {
+	a.WriteChirpPageAt(resp, req, page, chirpsDb, func(i int) pagination.Cursor {
+		return pagination.Cursor{CreatedAt: chirpsDb[i].CreatedAt, ID: chirpsDb[i].ID}
+	})
+}
+
+// WriteChirpPageAt is WriteChirpPage for timelines that are not ordered by
+// when the chirps were posted. cursor gives the position of the i-th chirp.
+func (a *ApiConfig) WriteChirpPageAt(resp http.ResponseWriter, req *http.Request, page pagination.Page, chirpsDb []database.Chirp, cursor func(i int) pagination.Cursor) {
+	if len(chirpsDb) > int(page.Limit) {
+		chirpsDb = chirpsDb[:page.Limit]
+		resp.Header().Set("Link", pagination.NextLink(req.URL, cursor(len(chirpsDb)-1).Encode()))
+	}
+
+	chirpsJson, err := a.ChirpsToJson(req.Context(), a.Viewer(req), chirpsDb)
+	if err != nil {
+		ErrorJsonResp(resp, err, FAILEDCODE)
+		return
 	}
-	return cleanStr
+
+	jsonData, err := json.Marshal(chirpsJson)
+	if err != nil {
+		ErrorJsonResp(resp, err, FAILEDCODE)
+		return
+	}
+
+	resp.Header().Set("Content-type", "application/json")
+	resp.WriteHeader(OKCODE)
+	resp.Write(jsonData)
 }
 
-func ValidateChirp(chirp string, chripLen int) bool {
-	return len(chirp) <= chripLen
+func (a *ApiConfig) MiddlewareAddChirp(limits ChirpLimits) http.Handler {
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		userId, err := a.AuthenticatedUser(req)
+		if err != nil {
+			ErrorJsonResp(resp, err, UNAUTHORIZED)
+			return
+		}
+
+		resData := struct {
+			Body       string           `json:"body"`
+			InReplyTo  string           `json:"in_reply_to"`
+			ChirpKind  string           `json:"chirp_kind"`
+			RefChirpID string           `json:"ref_chirp_id"`
+			MediaIDs   []string         `json:"media_ids"`
+			Poll       *PollRequestJson `json:"poll"`
+			Status     string           `json:"status"`
+			PublishAt  string           `json:"publish_at"`
+		}{}
+
+		reqData, err := io.ReadAll(req.Body)
+
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+
+		}
+
+		err = json.Unmarshal(reqData, &resData)
+
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		inReplyTo, err := ParseOptionalUUID(resData.InReplyTo)
+		if err != nil {
+			ErrorJsonResp(resp, fmt.Errorf("invalid in_reply_to: %v", err), FAILEDCODE)
+			return
+		}
+
+		if inReplyTo.Valid {
+			// a block in either direction hides the parent, so it reads as missing
+			_, err = a.DbQueries.GetVisibleChirp(req.Context(), database.GetVisibleChirpParams{
+				ID:       inReplyTo.UUID,
+				ViewerID: uuid.NullUUID{UUID: userId, Valid: true}})
+
+			if errors.Is(err, sql.ErrNoRows) {
+				ErrorJsonResp(resp, fmt.Errorf("chirp %v being replied to not found", inReplyTo.UUID), FAILEDCODE)
+				return
+			}
+			if err != nil {
+				ErrorJsonResp(resp, err, FAILEDCODE)
+				return
+			}
+		}
+
+		kind, refChirpId, err := a.ParseChirpRef(req.Context(), userId, resData.ChirpKind, resData.RefChirpID)
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		mediaIds, err := ParseMediaIDs(resData.MediaIDs)
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		poll, err := a.ParsePoll(resData.Poll, limits.URLWeight)
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		if resData.Status == "" {
+			resData.Status = CHIRP_PUBLISHED
+		}
+
+		status, publishAt, err := ParseChirpStatus(resData.Status, resData.PublishAt, time.Now())
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		// a poll's closing time is fixed when it is created, and a rechirp
+		// has nothing to edit
+		if status != CHIRP_PUBLISHED && (kind == CHIRP_RECHIRP || poll != nil) {
+			ErrorJsonResp(resp, fmt.Errorf("a %s chirp cannot be a %s or have a poll", status, CHIRP_RECHIRP), FAILEDCODE)
+			return
+		}
+
+		if kind == CHIRP_RECHIRP {
+			if resData.Body != "" || inReplyTo.Valid || len(mediaIds) > 0 || poll != nil {
+				ErrorJsonResp(resp, fmt.Errorf("a %s cannot have a body, in_reply_to, media or a poll", CHIRP_RECHIRP), FAILEDCODE)
+				return
+			}
+			a.CreateRechirp(resp, req, userId, refChirpId)
+			return
+		}
+
+		if !a.CheckChirpLength(resp, req, userId, resData.Body, limits) {
+			return
+		}
+
+		var moderated moderation.Result
+		if status != CHIRP_PUBLISHED {
+			var ok bool
+			moderated, ok = a.ModerateWithoutHold(resp, resData.Body)
+			if !ok {
+				return
+			}
+		} else {
+			moderated = a.Moderator.Check(resData.Body)
+		}
+		if poll != nil {
+			moderated = moderated.Merge(poll.Moderation)
+		}
+		if moderated.Action == moderation.PolicyReject {
+			ErrorJsonResp(resp, fmt.Errorf("chirp contains words that are not allowed: %s", strings.Join(moderated.MatchedWords(), ", ")), UNPROCESSABLECODE)
+			return
+		}
+
+		tx, err := a.Db.BeginTx(req.Context(), nil)
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+		defer tx.Rollback()
+
+		queries := a.DbQueries.WithTx(tx)
+
+		if moderated.Action == moderation.PolicyHold {
+			heldDb, err := queries.CreateHeldChirp(req.Context(), database.CreateHeldChirpParams{
+				ID:           uuid.New(),
+				CreatedAt:    time.Now(),
+				Body:         resData.Body,
+				UserID:       userId,
+				MatchedWords: moderated.MatchedWords(),
+				InReplyTo:    inReplyTo,
+				RefChirpID:   refChirpId})
+
+			if err != nil {
+				ErrorJsonResp(resp, err, FAILEDCODE)
+				return
+			}
+
+			if poll != nil {
+				err = StorePoll(req.Context(), queries, poll, uuid.NullUUID{}, uuid.NullUUID{UUID: heldDb.ID, Valid: true})
+				if err != nil {
+					ErrorJsonResp(resp, err, FAILEDCODE)
+					return
+				}
+			}
+
+			// held media stays with the held chirp until it is approved
+			if len(mediaIds) > 0 {
+				held, err := queries.HoldMedia(req.Context(), database.HoldMediaParams{
+					HeldChirpID: uuid.NullUUID{UUID: heldDb.ID, Valid: true},
+					Ids:         mediaIds,
+					UserID:      userId})
+
+				if err != nil {
+					ErrorJsonResp(resp, err, FAILEDCODE)
+					return
+				}
+				if held != int64(len(mediaIds)) {
+					ErrorJsonResp(resp, fmt.Errorf("media not found or already attached"), FAILEDCODE)
+					return
+				}
+			}
+
+			err = tx.Commit()
+			if err != nil {
+				ErrorJsonResp(resp, err, FAILEDCODE)
+				return
+			}
+
+			jsonData, _ := json.Marshal(HeldChirpDbToJson(heldDb))
+			resp.Header().Set("Content-Type", "application/json")
+			resp.WriteHeader(ACCEPTEDCODE)
+			resp.Write(jsonData)
+			return
+		}
+
+		chirpDbData, err := queries.CreateChirps(req.Context(), database.CreateChirpsParams{
+			ID:         uuid.New(),
+			CreatedAt:  time.Now(),
+			UpdatedAt:  time.Now(),
+			Body:       moderated.Text,
+			UserID:     userId,
+			InReplyTo:  inReplyTo,
+			ChirpKind:  kind,
+			RefChirpID: refChirpId,
+			Status:     status,
+			PublishAt:  publishAt})
+
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		// drafts get their tags and mentions when they are published
+		if status == CHIRP_PUBLISHED {
+			err = a.PublishChirp(req.Context(), queries, chirpDbData)
+			if err != nil {
+				ErrorJsonResp(resp, err, FAILEDCODE)
+				return
+			}
+		}
+
+		if poll != nil {
+			err = StorePoll(req.Context(), queries, poll, uuid.NullUUID{UUID: chirpDbData.ID, Valid: true}, uuid.NullUUID{})
+			if err != nil {
+				ErrorJsonResp(resp, err, FAILEDCODE)
+				return
+			}
+		}
+
+		if len(mediaIds) > 0 {
+			attached, err := queries.AttachMedia(req.Context(), database.AttachMediaParams{
+				ChirpID: uuid.NullUUID{UUID: chirpDbData.ID, Valid: true},
+				Ids:     mediaIds,
+				UserID:  userId})
+
+			if err != nil {
+				ErrorJsonResp(resp, err, FAILEDCODE)
+				return
+			}
+			if attached != int64(len(mediaIds)) {
+				ErrorJsonResp(resp, fmt.Errorf("media not found or already attached"), FAILEDCODE)
+				return
+			}
+		}
+
+		err = tx.Commit()
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		if status == CHIRP_PUBLISHED {
+			a.ChirpPublished(chirpDbData)
+		}
+		a.ChirpResp(resp, req, chirpDbData, NEWCODE)
+
+	})
+
 }
 
-func (a *ApiConfig) MiddlewareGetChirps(id uuid.UUID) http.Handler {
+// MiddlewareDeleteChirp soft-deletes one of the caller's chirps. It drops out
+// of every listing straight away but stays restorable, and shows as a
+// tombstone in threads, until the purge job removes it for good. Undoing a
+// rechirp leaves nothing worth restoring, so those rows go immediately.
+func (a *ApiConfig) MiddlewareDeleteChirp() http.Handler {
 	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		userId, err := a.AuthenticatedUser(req)
+		if err != nil {
+			ErrorJsonResp(resp, err, UNAUTHORIZED)
+			return
+		}
+
+		id, err := uuid.Parse(req.PathValue("chirpID"))
+		if err != nil {
+			ErrorJsonResp(resp, fmt.Errorf("invalid chirp id: %v", err), FAILEDCODE)
+			return
+		}
 
 		chirpDb, err := a.DbQueries.GetChirps(req.Context(), id)
+		if errors.Is(err, sql.ErrNoRows) || (err == nil && chirpDb.DeletedAt.Valid) {
+			ErrorJsonResp(resp, fmt.Errorf("chirp %v not found", id), NOTFOUNDCODE)
+			return
+		}
 		if err != nil {
 			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
 		}
 
-		chirpJson := ChirpJson{
-			ID:        chirpDb.ID,
-			CreatedAt: chirpDb.CreatedAt,
-			UpdatedAt: chirpDb.UpdatedAt,
-			Body:      chirpDb.Body,
-			UserID:    chirpDb.UserID}
+		if chirpDb.UserID != userId {
+			ErrorJsonResp(resp, fmt.Errorf("only the author can delete this chirp"), FORBIDDENCODE)
+			return
+		}
+
+		if chirpDb.ChirpKind == CHIRP_RECHIRP {
+			err = a.DbQueries.DeleteChirp(req.Context(), database.DeleteChirpParams{ID: chirpDb.ID, UserID: userId})
+			if err != nil {
+				ErrorJsonResp(resp, err, FAILEDCODE)
+				return
+			}
+			resp.WriteHeader(NOCONTENTCODE)
+			return
+		}
 
-		jsonData, err := json.Marshal(chirpJson)
+		tx, err := a.Db.BeginTx(req.Context(), nil)
 		if err != nil {
 			ErrorJsonResp(resp, err, FAILEDCODE)
 			return
-
 		}
+		defer tx.Rollback()
 
-		resp.Header().Set("Content-type", "application/json")
-		resp.WriteHeader(OKCODE)
-		resp.Write(jsonData)
-
-	})
+		queries := a.DbQueries.WithTx(tx)
+		deletedAt := sql.NullTime{Time: time.Now(), Valid: true}
 
-}
+		deleted, err := queries.SoftDeleteChirp(req.Context(), database.SoftDeleteChirpParams{
+			DeletedAt: deletedAt,
+			ID:        chirpDb.ID,
+			UserID:    userId})
 
-func (a *ApiConfig) MiddlewareGetAllChirps() http.Handler {
-	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
-		chirpsDb, err := a.DbQueries.GetAllChirps(req.Context())
 		if err != nil {
 			ErrorJsonResp(resp, err, FAILEDCODE)
 			return
-
 		}
-
-		chirpsJson := []ChirpJson{}
-
-		for _, c := range chirpsDb {
-			chirpsJson = append(chirpsJson, ChirpJson{
-				ID:        c.ID,
-				CreatedAt: c.CreatedAt,
-				UpdatedAt: c.UpdatedAt,
-				Body:      c.Body,
-				UserID:    c.UserID})
+		if deleted == 0 {
+			ErrorJsonResp(resp, fmt.Errorf("chirp %v not found", id), NOTFOUNDCODE)
+			return
 		}
 
-		slices.SortFunc(chirpsJson, func(a, b ChirpJson) int {
-			return a.CreatedAt.Compare(b.CreatedAt)
-		})
+		// rechirps have nothing left to show once the original is gone, so
+		// they go with it, stamped the same so a restore brings them back;
+		// quotes keep their own body and reference a tombstone instead
+		err = queries.SoftDeleteRechirpsOf(req.Context(), database.SoftDeleteRechirpsOfParams{
+			DeletedAt:  deletedAt,
+			RefChirpID: uuid.NullUUID{UUID: chirpDb.ID, Valid: true}})
 
-		jsonData, err := json.Marshal(chirpsJson)
 		if err != nil {
 			ErrorJsonResp(resp, err, FAILEDCODE)
 			return
+		}
 
+		err = tx.Commit()
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
 		}
 
-		resp.Header().Set("Content-type", "application/json")
-		resp.WriteHeader(OKCODE)
-		resp.Write(jsonData)
+		resp.WriteHeader(NOCONTENTCODE)
 	})
 }
 
-func (a *ApiConfig) MiddlewareAddChirp(chirpLen int, mux *http.ServeMux) http.Handler {
+func (a *ApiConfig) MiddleWareCreateUserHandle() http.Handler {
+
 	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
-		resData := struct {
-			Body   string `json:"body"`
-			UserId string `json:"user_id"`
-		}{}
 
+		emailStruct := &UserJson{}
 		reqData, err := io.ReadAll(req.Body)
-
 		if err != nil {
 			ErrorJsonResp(resp, err, FAILEDCODE)
 			return
-
 		}
 
-		err = json.Unmarshal(reqData, &resData)
-
+		err = json.Unmarshal(reqData, emailStruct)
 		if err != nil {
 			ErrorJsonResp(resp, err, FAILEDCODE)
 			return
 		}
 
-		if !ValidateChirp(resData.Body, chirpLen) {
-			ErrorJsonResp(resp, fmt.Errorf("error: Chirp is too long"), FAILEDCODE)
+		username, err := ParseUsername(emailStruct.Username)
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
 			return
 		}
 
-		userId, err := uuid.Parse(resData.UserId)
+		HashedPassword, err := auth.HashPassword(emailStruct.Password)
 		if err != nil {
 			ErrorJsonResp(resp, err, FAILEDCODE)
 			return
 		}
-		chirpDbData, err := a.DbQueries.CreateChirps(req.Context(), database.CreateChirpsParams{
-			ID:        uuid.New(),
-			CreatedAt: time.Now(),
-			UpdatedAt: time.Now(),
-			Body:      SanatizeProfane(resData.Body),
-			UserID:    userId})
 
+		params := database.CreateUserParams{
+			ID:             uuid.New(),
+			CreatedAt:      time.Now(),
+			UpdatedAt:      time.Now(),
+			Email:          emailStruct.Email,
+			HashedPassword: HashedPassword,
+			Username:       username,
+		}
+		userDbQuiery, err := a.DbQueries.CreateUser(req.Context(), params)
+		if IsUniqueViolation(err) {
+			ErrorJsonResp(resp, fmt.Errorf("email or username is already in use"), CONFLICTCODE)
+			return
+		}
 		if err != nil {
 			ErrorJsonResp(resp, err, FAILEDCODE)
 			return
 		}
 
-		err = HandleHandler(
-			mux,
-			Handler{Ns: BACKEND_NS, Handle: a.MiddlewareGetChirps(chirpDbData.ID)},
-			fmt.Sprintf("/chirps/%s", chirpDbData.ID.String()),
-			GET_METHOD)
+		userDbStruct := UserDbToJson(userDbQuiery)
 
+		userData, err := json.Marshal(userDbStruct)
 		if err != nil {
 			ErrorJsonResp(resp, err, FAILEDCODE)
 			return
 		}
 
-		ChirpData := struct {
-			ID        uuid.UUID `json:"id"`
-			CreatedAt time.Time `json:"created_at"`
-			UpdatedAt time.Time `json:"updated_at"`
-			Body      string    `json:"body"`
-			UserID    uuid.UUID `json:"user_id"`
-		}{
-			ID:        chirpDbData.ID,
-			CreatedAt: chirpDbData.CreatedAt,
-			UpdatedAt: chirpDbData.UpdatedAt,
-			Body:      chirpDbData.Body,
-			UserID:    chirpDbData.UserID}
-
-		jsonData, _ := json.Marshal(ChirpData)
 		resp.Header().Set("Content-Type", "application/json")
 		resp.WriteHeader(NEWCODE)
-		resp.Write(jsonData)
+		resp.Write(userData)
 
 	})
-
 }
 
-func (a *ApiConfig) MiddleWareCreateUserHandle() http.Handler {
-
+func (a *ApiConfig) MiddlewareUpdateUserHandle() http.Handler {
 	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		userId, err := a.AuthenticatedUser(req)
+		if err != nil {
+			ErrorJsonResp(resp, err, UNAUTHORIZED)
+			return
+		}
 
-		emailStruct := &UserJson{}
+		userJson := &UserJson{}
 		reqData, err := io.ReadAll(req.Body)
 		if err != nil {
 			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
 		}
 
-		err = json.Unmarshal(reqData, emailStruct)
+		err = json.Unmarshal(reqData, userJson)
 		if err != nil {
 			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
 		}
 
-		HashedPassword, err := auth.HashPassword(emailStruct.Password)
+		if userJson.Email == "" && userJson.Password == "" && userJson.Username == "" {
+			ErrorJsonResp(resp, fmt.Errorf("email, password or username must be provided"), FAILEDCODE)
+			return
+		}
+
+		userDb, err := a.DbQueries.GetUserFromId(req.Context(), userId)
+		if errors.Is(err, sql.ErrNoRows) {
+			ErrorJsonResp(resp, fmt.Errorf("user %v not found", userId), NOTFOUNDCODE)
+			return
+		}
 		if err != nil {
 			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
 		}
 
-		params := database.CreateUserParams{
-			ID:             uuid.New(),
-			CreatedAt:      time.Now(),
+		params := database.UpdateUserParams{
+			ID:             userDb.ID,
+			Email:          userDb.Email,
+			HashedPassword: userDb.HashedPassword,
+			Username:       userDb.Username,
 			UpdatedAt:      time.Now(),
-			Email:          emailStruct.Email,
-			HashedPassword: HashedPassword,
 		}
-		userDbQuiery, err := a.DbQueries.CreateUser(req.Context(), params)
+
+		if userJson.Email != "" {
+			params.Email = userJson.Email
+		}
+
+		if userJson.Username != "" {
+			params.Username, err = ParseUsername(userJson.Username)
+			if err != nil {
+				ErrorJsonResp(resp, err, FAILEDCODE)
+				return
+			}
+		}
+
+		if userJson.Password != "" {
+			params.HashedPassword, err = auth.HashPassword(userJson.Password)
+			if err != nil {
+				ErrorJsonResp(resp, err, FAILEDCODE)
+				return
+			}
+		}
+
+		userDb, err = a.DbQueries.UpdateUser(req.Context(), params)
+		if IsUniqueViolation(err) {
+			ErrorJsonResp(resp, fmt.Errorf("email or username is already in use"), CONFLICTCODE)
+			return
+		}
 		if err != nil {
 			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
 		}
 
-		userDbStruct := UserDbJson{
-			ID:         userDbQuiery.ID,
-			CreatedAt:  userDbQuiery.CreatedAt,
-			UpdateddAt: userDbQuiery.CreatedAt,
-			Email:      userDbQuiery.Email}
+		if userJson.Password != "" {
+			err = a.DbQueries.RevokeUserRefreshTokens(req.Context(), userDb.ID)
+			if err != nil {
+				ErrorJsonResp(resp, err, FAILEDCODE)
+				return
+			}
+		}
 
-		userData, err := json.Marshal(userDbStruct)
+		userData, err := json.Marshal(UserDbToJson(userDb))
 		if err != nil {
 			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
 		}
 
-		resp.Header().Set("Content-Type", "application:json")
-		resp.WriteHeader(NEWCODE)
+		resp.Header().Set("Content-Type", "application/json")
+		resp.WriteHeader(OKCODE)
 		resp.Write(userData)
-
 	})
 }
 
@@ -361,40 +1261,178 @@ func (a *ApiConfig) MiddlewareLoginHandler() http.Handler {
 		reqData, err := io.ReadAll(req.Body)
 		if err != nil {
 			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
 		}
 
 		err = json.Unmarshal(reqData, userJson)
 		if err != nil {
 			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
 		}
 
 		userDb, err := a.DbQueries.GetUserFromEmail(req.Context(), userJson.Email)
 		if err != nil {
-			ErrorJsonResp(resp, err, FAILEDCODE)
+			ErrorJsonResp(resp, fmt.Errorf("incorrect email or password"), UNAUTHORIZED)
+			return
 		}
 
 		err = auth.CheckPasswordHash(userJson.Password, userDb.HashedPassword)
 		if err != nil {
-			ErrorJsonResp(resp, err, UNAUTHORIZED)
+			ErrorJsonResp(resp, fmt.Errorf("incorrect email or password"), UNAUTHORIZED)
+			return
+		}
+
+		if userDb.DeletedAt.Valid {
+			ErrorJsonResp(resp, fmt.Errorf("account was deleted, restore it with POST %s/users/restore", BACKEND_NS), FORBIDDENCODE)
+			return
 		}
 
-		userDbjson := UserDbJson{ID: userDb.ID,
-			CreatedAt:  userDb.CreatedAt,
-			UpdateddAt: userDb.CreatedAt,
-			Email:      userDb.Email}
+		token, err := auth.MakeJWT(userDb.ID, a.JwtSecret, ACCESSTOKENEXPIRE)
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		refreshToken, err := a.IssueRefreshToken(req.Context(), userDb.ID, uuid.New())
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		userDbjson := LoginJson{
+			UserDbJson:   UserDbToJson(userDb),
+			Token:        token,
+			RefreshToken: refreshToken}
 
 		userDbjsonData, err := json.Marshal(userDbjson)
 		if err != nil {
 			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
 		}
 
-		resp.Header().Set("Content-type", "application:json")
+		resp.Header().Set("Content-type", "application/json")
 		resp.WriteHeader(OKCODE)
 		resp.Write(userDbjsonData)
 
 	})
 }
 
+func (a *ApiConfig) MiddlewareRefreshHandler() http.Handler {
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		refreshToken, err := auth.GetBearerToken(req.Header)
+		if err != nil {
+			ErrorJsonResp(resp, err, UNAUTHORIZED)
+			return
+		}
+
+		tokenDb, err := a.DbQueries.GetRefreshToken(req.Context(), refreshToken)
+		if err != nil {
+			ErrorJsonResp(resp, fmt.Errorf("invalid refresh token"), UNAUTHORIZED)
+			return
+		}
+
+		if tokenDb.RevokedAt.Valid {
+			// a rotated token coming back means it was copied, so end the whole session
+			if tokenDb.ReplacedBy.Valid {
+				a.DbQueries.RevokeRefreshTokenFamily(req.Context(), tokenDb.FamilyID)
+			}
+			ErrorJsonResp(resp, fmt.Errorf("refresh token has been revoked"), UNAUTHORIZED)
+			return
+		}
+
+		if time.Now().UTC().After(tokenDb.ExpiresAt) {
+			ErrorJsonResp(resp, fmt.Errorf("refresh token has expired"), UNAUTHORIZED)
+			return
+		}
+
+		newRefreshToken, err := auth.MakeRefreshToken()
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		// the old token is only marked replaced if its successor is stored
+		// too, so a failed refresh can be retried without looking like reuse
+		tx, err := a.Db.BeginTx(req.Context(), nil)
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+		defer tx.Rollback()
+
+		queries := a.DbQueries.WithTx(tx)
+
+		_, err = queries.RotateRefreshToken(req.Context(), database.RotateRefreshTokenParams{
+			ReplacedBy: newRefreshToken,
+			Token:      tokenDb.Token})
+
+		if errors.Is(err, sql.ErrNoRows) {
+			tx.Rollback()
+			// another request rotated the same token first
+			a.DbQueries.RevokeRefreshTokenFamily(req.Context(), tokenDb.FamilyID)
+			ErrorJsonResp(resp, fmt.Errorf("refresh token has been revoked"), UNAUTHORIZED)
+			return
+		}
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		_, err = queries.CreateRefreshToken(req.Context(), database.CreateRefreshTokenParams{
+			Token:     newRefreshToken,
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+			UserID:    tokenDb.UserID,
+			FamilyID:  tokenDb.FamilyID,
+			ExpiresAt: time.Now().UTC().Add(REFRESHTOKENEXPIRE)})
+
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		err = tx.Commit()
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		token, err := auth.MakeJWT(tokenDb.UserID, a.JwtSecret, ACCESSTOKENEXPIRE)
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		jsonData, err := json.Marshal(TokenJson{Token: token, RefreshToken: newRefreshToken})
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		resp.Header().Set("Content-type", "application/json")
+		resp.WriteHeader(OKCODE)
+		resp.Write(jsonData)
+	})
+}
+
+func (a *ApiConfig) MiddlewareRevokeHandler() http.Handler {
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		refreshToken, err := auth.GetBearerToken(req.Header)
+		if err != nil {
+			ErrorJsonResp(resp, err, UNAUTHORIZED)
+			return
+		}
+
+		err = a.DbQueries.RevokeRefreshToken(req.Context(), refreshToken)
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		resp.WriteHeader(NOCONTENTCODE)
+	})
+}
+
 func HandleHandler(mux *http.ServeMux, handle Handler, hndlName, mthdName string) error {
 
 	if handle.Ns == "" {
@@ -435,8 +1473,88 @@ func main() {
 	mux := http.NewServeMux()
 
 	a := ApiConfig{}
+	a.Db = db
 	a.DbQueries = database.New(db)
 
+	a.JwtSecret = os.Getenv("JWT_SECRET")
+	if a.JwtSecret == "" {
+		fmt.Println("JWT_SECRET must be set")
+		os.Exit(1)
+	}
+
+	a.PolkaKey = os.Getenv("POLKA_KEY")
+	if a.PolkaKey == "" {
+		fmt.Println("POLKA_KEY must be set")
+		os.Exit(1)
+	}
+
+	a.AdminKey = os.Getenv("ADMIN_KEY")
+	a.ModerationFile = os.Getenv("MODERATION_FILE")
+	a.Moderator = moderation.NewFilter(CENSORSTR)
+
+	err = a.ReloadModeration(context.Background())
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	chirpLimits, err := LoadChirpLimits()
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	editWindow, err := LoadEditWindow()
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	fanoutLimit, err := LoadFanoutLimit()
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	a.Fanout = fanout.NewWorker(a.Db, a.DbQueries, int32(fanoutLimit), fanout.DefaultInterval)
+	go a.Fanout.Run(context.Background())
+
+	a.Previews = preview.NewWorker(a.DbQueries, preview.NewFetcher(), PREVIEWQUEUE)
+	for i := 0; i < PREVIEWWORKERS; i++ {
+		go a.Previews.Run(context.Background())
+	}
+
+	publishInterval, err := LoadPublishInterval()
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	scheduled := publisher.New(a.Db, a.DbQueries, publishInterval, a.PrepareScheduledChirp, a.ChirpPublished)
+	go scheduled.Run(context.Background())
+
+	mediaStore, err := LoadMediaStore()
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	a.Media = mediaStore
+
+	restoreWindow, err := LoadRestoreWindow()
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	purger := purge.New(a.Db, a.DbQueries, a.Media, a.Fanout, restoreWindow, purge.DefaultInterval)
+	go purger.Run(context.Background())
+
+	mediaMaxBytes, err := LoadMediaMaxBytes()
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
 	type handlerMap map[string]Handler
 	endpointMap := Handlers{}
 
@@ -446,18 +1564,86 @@ func main() {
 	// admin handlers
 	endpointMap["/reset"] = handlerMap{POST_METHOD: Handler{Ns: ADMIN_NS, Handle: a.MiddlewareReqResetHandle()}}
 	endpointMap["/metrics"] = handlerMap{GET_METHOD: Handler{Ns: ADMIN_NS, Handle: a.MiddlewareReqCheckHandle()}}
+	endpointMap["/moderation/words"] = handlerMap{
+		GET_METHOD: Handler{Ns: ADMIN_NS, Handle: a.MiddlewareAdminOnly(a.MiddlewareListModerationWords())},
+		PUT_METHOD: Handler{Ns: ADMIN_NS, Handle: a.MiddlewareAdminOnly(a.MiddlewareUpsertModerationWord())}}
+	endpointMap["/moderation/words/{word}"] = handlerMap{DELETE_METHOD: Handler{Ns: ADMIN_NS, Handle: a.MiddlewareAdminOnly(a.MiddlewareDeleteModerationWord())}}
+	endpointMap["/moderation/reload"] = handlerMap{POST_METHOD: Handler{Ns: ADMIN_NS, Handle: a.MiddlewareAdminOnly(a.MiddlewareReloadModeration())}}
+	endpointMap["/moderation/held"] = handlerMap{GET_METHOD: Handler{Ns: ADMIN_NS, Handle: a.MiddlewareAdminOnly(a.MiddlewareListHeldChirps())}}
+	endpointMap["/moderation/held/{heldID}"] = handlerMap{DELETE_METHOD: Handler{Ns: ADMIN_NS, Handle: a.MiddlewareAdminOnly(a.MiddlewareRejectHeldChirp())}}
+	endpointMap["/moderation/held/{heldID}/approve"] = handlerMap{POST_METHOD: Handler{Ns: ADMIN_NS, Handle: a.MiddlewareAdminOnly(a.MiddlewareApproveHeldChirp())}}
 
 	// api handlers
 	endpointMap["/chirps"] = handlerMap{
-		POST_METHOD: Handler{Ns: BACKEND_NS, Handle: a.MiddlewareAddChirp(140, mux)},
+		POST_METHOD: Handler{Ns: BACKEND_NS, Handle: a.MiddlewareAddChirp(chirpLimits)},
 		GET_METHOD:  Handler{Ns: BACKEND_NS, Handle: a.MiddlewareGetAllChirps()}}
-
-	endpointMap["/users"] = handlerMap{POST_METHOD: Handler{Ns: BACKEND_NS, Handle: a.MiddleWareCreateUserHandle()}}
+	endpointMap["/chirps/search"] = handlerMap{GET_METHOD: Handler{Ns: BACKEND_NS, Handle: a.MiddlewareSearchChirps()}}
+	endpointMap["/chirps/{chirpID}"] = handlerMap{
+		GET_METHOD:    Handler{Ns: BACKEND_NS, Handle: a.MiddlewareGetChirps()},
+		PUT_METHOD:    Handler{Ns: BACKEND_NS, Handle: a.MiddlewareEditChirp(chirpLimits, editWindow)},
+		DELETE_METHOD: Handler{Ns: BACKEND_NS, Handle: a.MiddlewareDeleteChirp()}}
+	endpointMap["/chirps/{chirpID}/bookmark"] = handlerMap{
+		POST_METHOD:   Handler{Ns: BACKEND_NS, Handle: a.MiddlewareBookmarkChirp()},
+		DELETE_METHOD: Handler{Ns: BACKEND_NS, Handle: a.MiddlewareUnbookmarkChirp()}}
+	endpointMap["/chirps/{chirpID}/history"] = handlerMap{GET_METHOD: Handler{Ns: BACKEND_NS, Handle: a.MiddlewareGetChirpHistory()}}
+	endpointMap["/chirps/{chirpID}/like"] = handlerMap{
+		POST_METHOD:   Handler{Ns: BACKEND_NS, Handle: a.MiddlewareLikeChirp()},
+		DELETE_METHOD: Handler{Ns: BACKEND_NS, Handle: a.MiddlewareUnlikeChirp()}}
+	endpointMap["/chirps/{chirpID}/restore"] = handlerMap{POST_METHOD: Handler{Ns: BACKEND_NS, Handle: a.MiddlewareRestoreChirp(restoreWindow)}}
+	endpointMap["/chirps/{chirpID}/poll/votes"] = handlerMap{POST_METHOD: Handler{Ns: BACKEND_NS, Handle: a.MiddlewareVotePoll()}}
+	endpointMap["/chirps/{chirpID}/thread"] = handlerMap{GET_METHOD: Handler{Ns: BACKEND_NS, Handle: a.MiddlewareGetThread()}}
+
+	endpointMap["/drafts"] = handlerMap{GET_METHOD: Handler{Ns: BACKEND_NS, Handle: a.MiddlewareGetDrafts()}}
+	endpointMap["/drafts/{chirpID}"] = handlerMap{
+		PUT_METHOD:    Handler{Ns: BACKEND_NS, Handle: a.MiddlewareUpdateDraft(chirpLimits)},
+		DELETE_METHOD: Handler{Ns: BACKEND_NS, Handle: a.MiddlewareDeleteDraft()}}
+	endpointMap["/lists"] = handlerMap{POST_METHOD: Handler{Ns: BACKEND_NS, Handle: a.MiddlewareCreateList()}}
+	endpointMap["/lists/{listID}"] = handlerMap{
+		GET_METHOD:    Handler{Ns: BACKEND_NS, Handle: a.MiddlewareGetList()},
+		PUT_METHOD:    Handler{Ns: BACKEND_NS, Handle: a.MiddlewareUpdateList()},
+		DELETE_METHOD: Handler{Ns: BACKEND_NS, Handle: a.MiddlewareDeleteList()}}
+	endpointMap["/lists/{listID}/chirps"] = handlerMap{GET_METHOD: Handler{Ns: BACKEND_NS, Handle: a.MiddlewareGetListChirps()}}
+	endpointMap["/lists/{listID}/members"] = handlerMap{GET_METHOD: Handler{Ns: BACKEND_NS, Handle: a.MiddlewareGetListMembers()}}
+	endpointMap["/lists/{listID}/members/{userID}"] = handlerMap{
+		POST_METHOD:   Handler{Ns: BACKEND_NS, Handle: a.MiddlewareAddListMember()},
+		DELETE_METHOD: Handler{Ns: BACKEND_NS, Handle: a.MiddlewareRemoveListMember()}}
+	endpointMap["/media"] = handlerMap{POST_METHOD: Handler{Ns: BACKEND_NS, Handle: a.MiddlewareUploadMedia(mediaMaxBytes)}}
+
+	endpointMap["/tags/trending"] = handlerMap{GET_METHOD: Handler{Ns: BACKEND_NS, Handle: a.MiddlewareGetTrendingTags()}}
+	endpointMap["/tags/{tag}/chirps"] = handlerMap{GET_METHOD: Handler{Ns: BACKEND_NS, Handle: a.MiddlewareGetTagChirps()}}
+
+	endpointMap["/users"] = handlerMap{
+		POST_METHOD:   Handler{Ns: BACKEND_NS, Handle: a.MiddleWareCreateUserHandle()},
+		PUT_METHOD:    Handler{Ns: BACKEND_NS, Handle: a.MiddlewareUpdateUserHandle()},
+		DELETE_METHOD: Handler{Ns: BACKEND_NS, Handle: a.MiddlewareDeleteUser()}}
+	endpointMap["/users/restore"] = handlerMap{POST_METHOD: Handler{Ns: BACKEND_NS, Handle: a.MiddlewareRestoreUser(restoreWindow)}}
+	endpointMap["/users/me/bookmarks"] = handlerMap{GET_METHOD: Handler{Ns: BACKEND_NS, Handle: a.MiddlewareGetBookmarks()}}
+	endpointMap["/users/me/mentions"] = handlerMap{GET_METHOD: Handler{Ns: BACKEND_NS, Handle: a.MiddlewareGetMentions()}}
+	endpointMap["/users/me/blocks"] = handlerMap{GET_METHOD: Handler{Ns: BACKEND_NS, Handle: a.MiddlewareGetBlocks()}}
+	endpointMap["/users/me/mutes"] = handlerMap{GET_METHOD: Handler{Ns: BACKEND_NS, Handle: a.MiddlewareGetMutes()}}
+	endpointMap["/users/{userID}"] = handlerMap{GET_METHOD: Handler{Ns: BACKEND_NS, Handle: a.MiddlewareGetProfile()}}
+	endpointMap["/users/{userID}/follow"] = handlerMap{
+		POST_METHOD:   Handler{Ns: BACKEND_NS, Handle: a.MiddlewareFollowUser()},
+		DELETE_METHOD: Handler{Ns: BACKEND_NS, Handle: a.MiddlewareUnfollowUser()}}
+	endpointMap["/users/{userID}/block"] = handlerMap{
+		POST_METHOD:   Handler{Ns: BACKEND_NS, Handle: a.MiddlewareBlockUser()},
+		DELETE_METHOD: Handler{Ns: BACKEND_NS, Handle: a.MiddlewareUnblockUser()}}
+	endpointMap["/users/{userID}/mute"] = handlerMap{
+		POST_METHOD:   Handler{Ns: BACKEND_NS, Handle: a.MiddlewareMuteUser()},
+		DELETE_METHOD: Handler{Ns: BACKEND_NS, Handle: a.MiddlewareUnmuteUser()}}
+	endpointMap["/users/{userID}/lists"] = handlerMap{GET_METHOD: Handler{Ns: BACKEND_NS, Handle: a.MiddlewareGetUserLists()}}
+	endpointMap["/users/{userID}/followers"] = handlerMap{GET_METHOD: Handler{Ns: BACKEND_NS, Handle: a.MiddlewareGetFollowers()}}
+	endpointMap["/users/{userID}/following"] = handlerMap{GET_METHOD: Handler{Ns: BACKEND_NS, Handle: a.MiddlewareGetFollowing()}}
+	endpointMap["/timeline/home"] = handlerMap{GET_METHOD: Handler{Ns: BACKEND_NS, Handle: a.MiddlewareGetHomeTimeline()}}
 	endpointMap["/healthz"] = handlerMap{POST_METHOD: Handler{Ns: BACKEND_NS, Handle: RedinisHandler()}}
 	endpointMap["/login"] = handlerMap{POST_METHOD: Handler{Ns: BACKEND_NS, Handle: a.MiddlewareLoginHandler()}}
+	endpointMap["/refresh"] = handlerMap{POST_METHOD: Handler{Ns: BACKEND_NS, Handle: a.MiddlewareRefreshHandler()}}
+	endpointMap["/revoke"] = handlerMap{POST_METHOD: Handler{Ns: BACKEND_NS, Handle: a.MiddlewareRevokeHandler()}}
+	endpointMap["/polka/webhooks"] = handlerMap{POST_METHOD: Handler{Ns: BACKEND_NS, Handle: a.MiddlewarePolkaWebhook()}}
 
 	// frontend handlers
 	endpointMap["/"] = handlerMap{GET_METHOD: Handler{Ns: FRONTEND_NS, Handle: a.MiddlewareIncHits(http.StripPrefix("/app", fileServeHandler))}}
+	endpointMap["/media/"] = handlerMap{GET_METHOD: Handler{Ns: FRONTEND_NS, Handle: a.MiddlewareIncHits(http.StripPrefix("/app/media", mediaStore))}}
 
 	HandleHandlers(mux, endpointMap)
 
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shahanmmiah/Chirpy/internal/database"
+	"github.com/shahanmmiah/Chirpy/internal/media"
+)
+
+// MULTIPARTSLACK is how far past the file size limit a whole upload request
+// may go, to leave room for the multipart framing and any other fields.
+const MULTIPARTSLACK = 64 << 10
+
+type MediaJson struct {
+	ID           uuid.UUID `json:"id"`
+	ContentType  string    `json:"content_type"`
+	URL          string    `json:"url"`
+	ThumbnailURL string    `json:"thumbnail_url"`
+	Width        int32     `json:"width"`
+	Height       int32     `json:"height"`
+	SizeBytes    int32     `json:"size_bytes"`
+}
+
+func (a *ApiConfig) MediaDbToJson(mediaDb database.MediaAttachment) MediaJson {
+	return MediaJson{
+		ID:           mediaDb.ID,
+		ContentType:  mediaDb.ContentType,
+		URL:          a.Media.URL(mediaDb.BlobKey),
+		ThumbnailURL: a.Media.URL(mediaDb.ThumbKey),
+		Width:        mediaDb.Width,
+		Height:       mediaDb.Height,
+		SizeBytes:    mediaDb.SizeBytes}
+}
+
+// ParseMediaIDs validates the media_ids of a new chirp. Whether the uploads
+// exist and belong to the author is checked when they are attached.
+func ParseMediaIDs(raw []string) ([]uuid.UUID, error) {
+	if len(raw) > MAXCHIRPMEDIA {
+		return nil, fmt.Errorf("at most %d media can be attached to a chirp", MAXCHIRPMEDIA)
+	}
+
+	ids := []uuid.UUID{}
+	seen := map[uuid.UUID]bool{}
+	for _, r := range raw {
+		id, err := uuid.Parse(r)
+		if err != nil {
+			return nil, fmt.Errorf("invalid media id: %v", err)
+		}
+		if seen[id] {
+			return nil, fmt.Errorf("media %v attached twice", id)
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// MiddlewareUploadMedia takes a multipart upload with the image in a "file"
+// field. The image is stored unattached; its id can then be passed in the
+// media_ids of a new chirp. Uploads left unattached are removed by the purger
+// after a day.
+func (a *ApiConfig) MiddlewareUploadMedia(maxBytes int) http.Handler {
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		userId, err := a.AuthenticatedUser(req)
+		if err != nil {
+			ErrorJsonResp(resp, err, UNAUTHORIZED)
+			return
+		}
+
+		req.Body = http.MaxBytesReader(resp, req.Body, int64(maxBytes)+MULTIPARTSLACK)
+
+		reader, err := req.MultipartReader()
+		if err != nil {
+			ErrorJsonResp(resp, fmt.Errorf("expected a multipart/form-data upload: %v", err), FAILEDCODE)
+			return
+		}
+
+		var part io.Reader
+		declared := ""
+		for part == nil {
+			p, err := reader.NextPart()
+			if err == io.EOF {
+				ErrorJsonResp(resp, fmt.Errorf("missing file field"), FAILEDCODE)
+				return
+			}
+			if err != nil {
+				uploadErrorResp(resp, err)
+				return
+			}
+			if p.FormName() == "file" {
+				part = p
+				declared = p.Header.Get("Content-Type")
+			}
+		}
+
+		if declared != "" && declared != "application/octet-stream" && !media.Allowed(declared) {
+			ErrorJsonResp(resp, media.ErrUnsupportedType, UNSUPPORTEDCODE)
+			return
+		}
+
+		data, err := io.ReadAll(io.LimitReader(part, int64(maxBytes)+1))
+		if err != nil {
+			uploadErrorResp(resp, err)
+			return
+		}
+		if len(data) > maxBytes {
+			ErrorJsonResp(resp, fmt.Errorf("file is larger than %d bytes", maxBytes), TOOLARGECODE)
+			return
+		}
+
+		img, err := media.Process(data)
+		if errors.Is(err, media.ErrUnsupportedType) {
+			ErrorJsonResp(resp, err, UNSUPPORTEDCODE)
+			return
+		}
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		id := uuid.New()
+		stored := database.MediaAttachment{
+			BlobKey:  id.String() + img.Ext,
+			ThumbKey: id.String() + "-thumb" + img.ThumbExt}
+
+		err = a.Media.Put(req.Context(), stored.BlobKey, bytes.NewReader(img.Data))
+		if err == nil {
+			err = a.Media.Put(req.Context(), stored.ThumbKey, bytes.NewReader(img.Thumb))
+		}
+		if err != nil {
+			media.DeleteBlobs(req.Context(), a.Media, []database.MediaAttachment{stored})
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		mediaDb, err := a.DbQueries.CreateMediaAttachment(req.Context(), database.CreateMediaAttachmentParams{
+			ID:          id,
+			UserID:      userId,
+			CreatedAt:   time.Now(),
+			ContentType: img.ContentType,
+			SizeBytes:   int32(len(img.Data)),
+			Width:       int32(img.Width),
+			Height:      int32(img.Height),
+			BlobKey:     stored.BlobKey,
+			ThumbKey:    stored.ThumbKey})
+
+		if err != nil {
+			media.DeleteBlobs(req.Context(), a.Media, []database.MediaAttachment{stored})
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		jsonData, err := json.Marshal(a.MediaDbToJson(mediaDb))
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		resp.Header().Set("Content-Type", "application/json")
+		resp.WriteHeader(NEWCODE)
+		resp.Write(jsonData)
+	})
+}
+
+func uploadErrorResp(resp http.ResponseWriter, err error) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		ErrorJsonResp(resp, fmt.Errorf("upload is larger than %d bytes", tooLarge.Limit), TOOLARGECODE)
+		return
+	}
+	ErrorJsonResp(resp, err, FAILEDCODE)
+}
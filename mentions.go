@@ -0,0 +1,37 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/shahanmmiah/Chirpy/internal/database"
+	"github.com/shahanmmiah/Chirpy/internal/pagination"
+)
+
+func (a *ApiConfig) MiddlewareGetMentions() http.Handler {
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		userId, err := a.AuthenticatedUser(req)
+		if err != nil {
+			ErrorJsonResp(resp, err, UNAUTHORIZED)
+			return
+		}
+
+		page, err := pagination.FromQuery(req.URL.Query())
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		chirpsDb, err := a.DbQueries.ListMentionChirps(req.Context(), database.ListMentionChirpsParams{
+			UserID:          userId,
+			CursorCreatedAt: page.CursorCreatedAt(),
+			CursorID:        page.CursorID(),
+			RowLimit:        page.FetchLimit()})
+
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		a.WriteChirpPage(resp, req, page, chirpsDb)
+	})
+}
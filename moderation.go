@@ -0,0 +1,369 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shahanmmiah/Chirpy/internal/auth"
+	"github.com/shahanmmiah/Chirpy/internal/database"
+	"github.com/shahanmmiah/Chirpy/internal/moderation"
+)
+
+var defaultModerationRules = []moderation.Rule{
+	{Word: "kerfuffle", Policy: moderation.PolicyCensor},
+	{Word: "sharbert", Policy: moderation.PolicyCensor},
+	{Word: "fornax", Policy: moderation.PolicyCensor},
+}
+
+type ModerationWordJson struct {
+	Word      string    `json:"word"`
+	Policy    string    `json:"policy"`
+	Allowed   bool      `json:"allowed"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type HeldChirpJson struct {
+	ID           uuid.UUID  `json:"id"`
+	CreatedAt    time.Time  `json:"created_at"`
+	Body         string     `json:"body"`
+	UserID       uuid.UUID  `json:"user_id"`
+	MatchedWords []string   `json:"matched_words"`
+	InReplyTo    *uuid.UUID `json:"in_reply_to"`
+	RefChirpID   *uuid.UUID `json:"ref_chirp_id"`
+}
+
+func HeldChirpDbToJson(heldDb database.HeldChirp) HeldChirpJson {
+	return HeldChirpJson{
+		ID:           heldDb.ID,
+		CreatedAt:    heldDb.CreatedAt,
+		Body:         heldDb.Body,
+		UserID:       heldDb.UserID,
+		MatchedWords: heldDb.MatchedWords,
+		InReplyTo:    NullUUIDToPtr(heldDb.InReplyTo),
+		RefChirpID:   NullUUIDToPtr(heldDb.RefChirpID)}
+}
+
+// ReloadModeration rebuilds the filter from the word list file (or the
+// built-in words when none is configured) with the database entries on top.
+func (a *ApiConfig) ReloadModeration(ctx context.Context) error {
+	rules := defaultModerationRules
+	allow := []string{}
+
+	if a.ModerationFile != "" {
+		var err error
+		rules, allow, err = moderation.LoadFile(a.ModerationFile)
+		if err != nil {
+			return fmt.Errorf("loading %v: %v", a.ModerationFile, err)
+		}
+	}
+
+	wordsDb, err := a.DbQueries.ListModerationWords(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, w := range wordsDb {
+		if w.Allowed {
+			allow = append(allow, w.Word)
+			continue
+		}
+		rules = append(rules, moderation.Rule{Word: w.Word, Policy: moderation.Policy(w.Policy)})
+	}
+
+	a.Moderator.Replace(rules, allow)
+	return nil
+}
+
+func (a *ApiConfig) MiddlewareAdminOnly(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		if a.AdminKey == "" {
+			ErrorJsonResp(resp, fmt.Errorf("Forbidden"), FORBIDDENCODE)
+			return
+		}
+
+		apiKey, err := auth.GetAPIKey(req.Header)
+		if err != nil {
+			ErrorJsonResp(resp, err, UNAUTHORIZED)
+			return
+		}
+
+		if subtle.ConstantTimeCompare([]byte(apiKey), []byte(a.AdminKey)) != 1 {
+			ErrorJsonResp(resp, fmt.Errorf("invalid api key"), UNAUTHORIZED)
+			return
+		}
+
+		handler.ServeHTTP(resp, req)
+	})
+}
+
+func (a *ApiConfig) MiddlewareListModerationWords() http.Handler {
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		wordsDb, err := a.DbQueries.ListModerationWords(req.Context())
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		wordsJson := []ModerationWordJson{}
+		for _, w := range wordsDb {
+			wordsJson = append(wordsJson, ModerationWordJson(w))
+		}
+
+		jsonData, err := json.Marshal(wordsJson)
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		resp.Header().Set("Content-Type", "application/json")
+		resp.WriteHeader(OKCODE)
+		resp.Write(jsonData)
+	})
+}
+
+func (a *ApiConfig) MiddlewareUpsertModerationWord() http.Handler {
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		wordData := struct {
+			Word    string `json:"word"`
+			Policy  string `json:"policy"`
+			Allowed bool   `json:"allowed"`
+		}{}
+
+		reqData, err := io.ReadAll(req.Body)
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		err = json.Unmarshal(reqData, &wordData)
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		word := strings.TrimSpace(wordData.Word)
+		if word == "" || strings.ContainsAny(word, " \t\n") {
+			ErrorJsonResp(resp, fmt.Errorf("word must be a single non-empty word"), FAILEDCODE)
+			return
+		}
+
+		policy, err := moderation.ParsePolicy(wordData.Policy)
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		wordDb, err := a.DbQueries.UpsertModerationWord(req.Context(), database.UpsertModerationWordParams{
+			Word:      word,
+			Policy:    string(policy),
+			Allowed:   wordData.Allowed,
+			CreatedAt: time.Now()})
+
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		err = a.ReloadModeration(req.Context())
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		jsonData, err := json.Marshal(ModerationWordJson(wordDb))
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		resp.Header().Set("Content-Type", "application/json")
+		resp.WriteHeader(OKCODE)
+		resp.Write(jsonData)
+	})
+}
+
+func (a *ApiConfig) MiddlewareDeleteModerationWord() http.Handler {
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		word := req.PathValue("word")
+
+		deleted, err := a.DbQueries.DeleteModerationWord(req.Context(), word)
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		if deleted == 0 {
+			ErrorJsonResp(resp, fmt.Errorf("word %v not found", word), NOTFOUNDCODE)
+			return
+		}
+
+		err = a.ReloadModeration(req.Context())
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		resp.WriteHeader(NOCONTENTCODE)
+	})
+}
+
+func (a *ApiConfig) MiddlewareReloadModeration() http.Handler {
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		err := a.ReloadModeration(req.Context())
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		resp.WriteHeader(NOCONTENTCODE)
+	})
+}
+
+func (a *ApiConfig) MiddlewareListHeldChirps() http.Handler {
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		heldDb, err := a.DbQueries.ListHeldChirps(req.Context())
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		heldJson := []HeldChirpJson{}
+		for _, h := range heldDb {
+			heldJson = append(heldJson, HeldChirpDbToJson(h))
+		}
+
+		jsonData, err := json.Marshal(heldJson)
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		resp.Header().Set("Content-Type", "application/json")
+		resp.WriteHeader(OKCODE)
+		resp.Write(jsonData)
+	})
+}
+
+func (a *ApiConfig) MiddlewareApproveHeldChirp() http.Handler {
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		id, err := uuid.Parse(req.PathValue("heldID"))
+		if err != nil {
+			ErrorJsonResp(resp, fmt.Errorf("invalid held chirp id: %v", err), FAILEDCODE)
+			return
+		}
+
+		tx, err := a.Db.BeginTx(req.Context(), nil)
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+		defer tx.Rollback()
+
+		queries := a.DbQueries.WithTx(tx)
+
+		heldDb, err := queries.GetHeldChirp(req.Context(), id)
+		if errors.Is(err, sql.ErrNoRows) {
+			ErrorJsonResp(resp, fmt.Errorf("held chirp %v not found", id), NOTFOUNDCODE)
+			return
+		}
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		// an approved chirp still gets the censor rules applied
+		kind := CHIRP_ORIGINAL
+		if heldDb.RefChirpID.Valid {
+			kind = CHIRP_QUOTE
+		}
+
+		chirpDb, err := queries.CreateChirps(req.Context(), database.CreateChirpsParams{
+			ID:         uuid.New(),
+			CreatedAt:  time.Now(),
+			UpdatedAt:  time.Now(),
+			Body:       a.Moderator.Check(heldDb.Body).Text,
+			UserID:     heldDb.UserID,
+			InReplyTo:  heldDb.InReplyTo,
+			ChirpKind:  kind,
+			RefChirpID: heldDb.RefChirpID,
+			Status:     CHIRP_PUBLISHED})
+
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		// the poll moves over first so that publishing times it from now
+		err = queries.ReleaseHeldPoll(req.Context(), database.ReleaseHeldPollParams{
+			ChirpID:     uuid.NullUUID{UUID: chirpDb.ID, Valid: true},
+			HeldChirpID: uuid.NullUUID{UUID: heldDb.ID, Valid: true}})
+
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		err = a.PublishChirp(req.Context(), queries, chirpDb)
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		err = queries.ReleaseHeldMedia(req.Context(), database.ReleaseHeldMediaParams{
+			ChirpID:     uuid.NullUUID{UUID: chirpDb.ID, Valid: true},
+			HeldChirpID: uuid.NullUUID{UUID: heldDb.ID, Valid: true}})
+
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		_, err = queries.DeleteHeldChirp(req.Context(), heldDb.ID)
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		err = tx.Commit()
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		a.ChirpPublished(chirpDb)
+		a.ChirpResp(resp, req, chirpDb, NEWCODE)
+	})
+}
+
+func (a *ApiConfig) MiddlewareRejectHeldChirp() http.Handler {
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		id, err := uuid.Parse(req.PathValue("heldID"))
+		if err != nil {
+			ErrorJsonResp(resp, fmt.Errorf("invalid held chirp id: %v", err), FAILEDCODE)
+			return
+		}
+
+		deleted, err := a.DbQueries.DeleteHeldChirp(req.Context(), id)
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		if deleted == 0 {
+			ErrorJsonResp(resp, fmt.Errorf("held chirp %v not found", id), NOTFOUNDCODE)
+			return
+		}
+
+		resp.WriteHeader(NOCONTENTCODE)
+	})
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shahanmmiah/Chirpy/internal/auth"
+	"github.com/shahanmmiah/Chirpy/internal/database"
+)
+
+const POLKA_USER_UPGRADED = "user.upgraded"
+
+type PolkaEventJson struct {
+	ID    string `json:"id"`
+	Event string `json:"event"`
+	Data  struct {
+		UserID string `json:"user_id"`
+	} `json:"data"`
+}
+
+var errPolkaUserNotFound = errors.New("user not found")
+
+func (a *ApiConfig) MiddlewarePolkaWebhook() http.Handler {
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		apiKey, err := auth.GetAPIKey(req.Header)
+		if err != nil {
+			ErrorJsonResp(resp, err, UNAUTHORIZED)
+			return
+		}
+
+		if subtle.ConstantTimeCompare([]byte(apiKey), []byte(a.PolkaKey)) != 1 {
+			ErrorJsonResp(resp, fmt.Errorf("invalid api key"), UNAUTHORIZED)
+			return
+		}
+
+		reqData, err := io.ReadAll(req.Body)
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		eventJson := PolkaEventJson{}
+		err = json.Unmarshal(reqData, &eventJson)
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		// polka retries until it sees a 2xx, so acknowledge events we don't act on
+		if eventJson.Event != POLKA_USER_UPGRADED {
+			resp.WriteHeader(NOCONTENTCODE)
+			return
+		}
+
+		userId, err := uuid.Parse(eventJson.Data.UserID)
+		if err != nil {
+			ErrorJsonResp(resp, fmt.Errorf("invalid user_id: %v", err), FAILEDCODE)
+			return
+		}
+
+		err = a.UpgradeUserFromEvent(req.Context(), eventJson, userId)
+		if errors.Is(err, errPolkaUserNotFound) {
+			ErrorJsonResp(resp, fmt.Errorf("user %v not found", userId), NOTFOUNDCODE)
+			return
+		}
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		resp.WriteHeader(NOCONTENTCODE)
+	})
+}
+
+// UpgradeUserFromEvent records the event id and upgrades the user in one
+// transaction, so a redelivered event is a no-op. A deleted account is not
+// found, and its event is not recorded.
+func (a *ApiConfig) UpgradeUserFromEvent(ctx context.Context, eventJson PolkaEventJson, userId uuid.UUID) error {
+	tx, err := a.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	queries := a.DbQueries.WithTx(tx)
+
+	if eventJson.ID != "" {
+		recorded, err := queries.RecordPolkaEvent(ctx, database.RecordPolkaEventParams{
+			ID:         eventJson.ID,
+			Event:      eventJson.Event,
+			UserID:     userId,
+			ReceivedAt: time.Now()})
+
+		if err != nil {
+			return err
+		}
+
+		if recorded == 0 {
+			return nil
+		}
+	}
+
+	upgraded, err := queries.UpgradeUserToChirpyRed(ctx, database.UpgradeUserToChirpyRedParams{
+		ID:        userId,
+		UpdatedAt: time.Now()})
+
+	if err != nil {
+		return err
+	}
+
+	if upgraded == 0 {
+		return errPolkaUserNotFound
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPolkaWebhookWithoutDatabase(t *testing.T) {
+	a := &ApiConfig{PolkaKey: "f271c81ff7084ee5b99a5091b42d486e"}
+
+	cases := []struct {
+		InputHeader  string
+		InputBody    string
+		ExpectedCode int
+	}{
+		{
+			InputHeader:  "",
+			InputBody:    `{"event": "user.upgraded", "data": {"user_id": "3311741c-680c-4546-99f3-fc9efac2036c"}}`,
+			ExpectedCode: UNAUTHORIZED},
+		{
+			InputHeader:  "ApiKey not-the-key",
+			InputBody:    `{"event": "user.upgraded", "data": {"user_id": "3311741c-680c-4546-99f3-fc9efac2036c"}}`,
+			ExpectedCode: UNAUTHORIZED},
+		{
+			InputHeader:  "ApiKey f271c81ff7084ee5b99a5091b42d486e",
+			InputBody:    `{"id": "evt_1", "event": "user.payment_failed", "data": {"user_id": "3311741c-680c-4546-99f3-fc9efac2036c"}}`,
+			ExpectedCode: NOCONTENTCODE},
+		{
+			InputHeader:  "ApiKey f271c81ff7084ee5b99a5091b42d486e",
+			InputBody:    `{"event": "user.upgraded", "data": {"user_id": "not-a-uuid"}}`,
+			ExpectedCode: FAILEDCODE},
+		{
+			InputHeader:  "ApiKey f271c81ff7084ee5b99a5091b42d486e",
+			InputBody:    `{"event": `,
+			ExpectedCode: FAILEDCODE},
+	}
+	for _, c := range cases {
+
+		req := httptest.NewRequest(http.MethodPost, "/api/polka/webhooks", strings.NewReader(c.InputBody))
+		if c.InputHeader != "" {
+			req.Header.Set("Authorization", c.InputHeader)
+		}
+		resp := httptest.NewRecorder()
+
+		a.MiddlewarePolkaWebhook().ServeHTTP(resp, req)
+
+		if resp.Code != c.ExpectedCode {
+			t.Errorf("error status is %d should be %d for body %s", resp.Code, c.ExpectedCode, c.InputBody)
+		}
+
+	}
+}
@@ -0,0 +1,293 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shahanmmiah/Chirpy/internal/database"
+	"github.com/shahanmmiah/Chirpy/internal/moderation"
+)
+
+// PollRequestJson is the poll part of a new chirp. ExpiresIn is in seconds.
+type PollRequestJson struct {
+	Options   []string `json:"options"`
+	ExpiresIn int      `json:"expires_in"`
+}
+
+type PollJson struct {
+	ClosesAt   time.Time        `json:"closes_at"`
+	Closed     bool             `json:"closed"`
+	TotalVotes int32            `json:"total_votes"`
+	Options    []PollOptionJson `json:"options"`
+	VotedFor   *uuid.UUID       `json:"voted_for,omitempty"`
+}
+
+type PollOptionJson struct {
+	ID    uuid.UUID `json:"id"`
+	Text  string    `json:"text"`
+	Votes int32     `json:"votes"`
+}
+
+// NewPoll is a validated poll waiting to be stored with its chirp. Options
+// hold the censored text and Moderation the result for all of them, to be
+// merged with the chirp body's. Duration is how long it stays open once the
+// chirp is published.
+type NewPoll struct {
+	Options    []string
+	Duration   time.Duration
+	Moderation moderation.Result
+}
+
+// ParsePoll validates the poll on a new chirp, returning nil when there is
+// none. Options are measured the same way as chirp bodies, against their own
+// shorter limit.
+func (a *ApiConfig) ParsePoll(raw *PollRequestJson, urlWeight int) (*NewPoll, error) {
+	if raw == nil {
+		return nil, nil
+	}
+
+	if len(raw.Options) < POLLMINOPTIONS || len(raw.Options) > POLLMAXOPTIONS {
+		return nil, fmt.Errorf("a poll needs %d-%d options", POLLMINOPTIONS, POLLMAXOPTIONS)
+	}
+
+	expiresIn := time.Duration(raw.ExpiresIn) * time.Second
+	if expiresIn < POLLMINDURATION || expiresIn > POLLMAXDURATION {
+		return nil, fmt.Errorf("expires_in must be between %d and %d seconds", int(POLLMINDURATION.Seconds()), int(POLLMAXDURATION.Seconds()))
+	}
+
+	poll := &NewPoll{Duration: expiresIn}
+	seen := map[string]bool{}
+	for i, option := range raw.Options {
+		option = strings.TrimSpace(option)
+		if option == "" {
+			return nil, fmt.Errorf("poll option %d is empty", i+1)
+		}
+		if seen[strings.ToLower(option)] {
+			return nil, fmt.Errorf("poll option %q appears twice", option)
+		}
+		seen[strings.ToLower(option)] = true
+
+		length, ok := ValidateChirp(option, POLLOPTIONLEN, urlWeight)
+		if !ok {
+			return nil, fmt.Errorf("poll option %d is %d characters, limit is %d", i+1, length, POLLOPTIONLEN)
+		}
+
+		moderated := a.Moderator.Check(option)
+		poll.Moderation = poll.Moderation.Merge(moderated)
+		poll.Options = append(poll.Options, moderated.Text)
+	}
+
+	return poll, nil
+}
+
+// StorePoll saves poll against a chirp, or against a held chirp until it is
+// approved. It is timed from now; polls on chirps that are held, drafts or
+// scheduled are timed again by StartPoll when the chirp is published.
+func StorePoll(ctx context.Context, queries *database.Queries, poll *NewPoll, chirpId, heldChirpId uuid.NullUUID) error {
+	now := time.Now()
+	pollDb, err := queries.CreatePoll(ctx, database.CreatePollParams{
+		ID:          uuid.New(),
+		ChirpID:     chirpId,
+		HeldChirpID: heldChirpId,
+		ClosesAt:    now.Add(poll.Duration),
+		CreatedAt:   now})
+
+	if err != nil {
+		return err
+	}
+
+	for i, option := range poll.Options {
+		err = queries.CreatePollOption(ctx, database.CreatePollOptionParams{
+			ID:       uuid.New(),
+			PollID:   pollDb.ID,
+			Position: int32(i),
+			Body:     option})
+
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MiddlewareVotePoll records the caller's vote. Each user gets one vote per
+// poll, which the poll_votes primary key enforces.
+func (a *ApiConfig) MiddlewareVotePoll() http.Handler {
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		userId, err := a.AuthenticatedUser(req)
+		if err != nil {
+			ErrorJsonResp(resp, err, UNAUTHORIZED)
+			return
+		}
+
+		chirpId, err := uuid.Parse(req.PathValue("chirpID"))
+		if err != nil {
+			ErrorJsonResp(resp, fmt.Errorf("invalid chirp id: %v", err), FAILEDCODE)
+			return
+		}
+
+		resData := struct {
+			OptionID string `json:"option_id"`
+		}{}
+
+		reqData, err := io.ReadAll(req.Body)
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		err = json.Unmarshal(reqData, &resData)
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		optionId, err := uuid.Parse(resData.OptionID)
+		if err != nil {
+			ErrorJsonResp(resp, fmt.Errorf("invalid option_id: %v", err), FAILEDCODE)
+			return
+		}
+
+		chirpDb, err := a.DbQueries.GetVisibleChirp(req.Context(), database.GetVisibleChirpParams{
+			ID:       chirpId,
+			ViewerID: uuid.NullUUID{UUID: userId, Valid: true}})
+
+		if errors.Is(err, sql.ErrNoRows) {
+			ErrorJsonResp(resp, fmt.Errorf("chirp %v not found", chirpId), NOTFOUNDCODE)
+			return
+		}
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		pollDb, err := a.DbQueries.GetPollForChirp(req.Context(), uuid.NullUUID{UUID: chirpId, Valid: true})
+		if errors.Is(err, sql.ErrNoRows) {
+			ErrorJsonResp(resp, fmt.Errorf("chirp %v has no poll", chirpId), NOTFOUNDCODE)
+			return
+		}
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		now := time.Now()
+		if !now.Before(pollDb.ClosesAt) {
+			ErrorJsonResp(resp, fmt.Errorf("poll closed at %v", pollDb.ClosesAt), CONFLICTCODE)
+			return
+		}
+
+		_, err = a.DbQueries.GetPollOption(req.Context(), database.GetPollOptionParams{ID: optionId, PollID: pollDb.ID})
+		if errors.Is(err, sql.ErrNoRows) {
+			ErrorJsonResp(resp, fmt.Errorf("option %v is not part of this poll", optionId), FAILEDCODE)
+			return
+		}
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		tx, err := a.Db.BeginTx(req.Context(), nil)
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+		defer tx.Rollback()
+
+		queries := a.DbQueries.WithTx(tx)
+
+		// the insert checks the closing time again, in case it passed since
+		voted, err := queries.VoteInPoll(req.Context(), database.VoteInPollParams{
+			UserID:    userId,
+			CreatedAt: now,
+			PollID:    pollDb.ID,
+			OptionID:  optionId})
+
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+		if voted == 0 {
+			// nothing was inserted, either because of an earlier vote or
+			// because the poll closed after all
+			already, err := queries.HasVotedInPoll(req.Context(), database.HasVotedInPollParams{
+				PollID: pollDb.ID,
+				UserID: userId})
+
+			if err != nil {
+				ErrorJsonResp(resp, err, FAILEDCODE)
+				return
+			}
+			if already {
+				ErrorJsonResp(resp, fmt.Errorf("already voted in this poll"), CONFLICTCODE)
+			} else {
+				ErrorJsonResp(resp, fmt.Errorf("poll closed"), CONFLICTCODE)
+			}
+			return
+		}
+
+		err = queries.AddPollOptionVote(req.Context(), optionId)
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		err = tx.Commit()
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		a.ChirpResp(resp, req, chirpDb, OKCODE)
+	})
+}
+
+// pollsToJson builds the polls for a page of chirps, with the viewer's own
+// vote when there is a viewer. Polls past their closing time are marked
+// closed and their tallies are final.
+func (a *ApiConfig) pollsToJson(ctx context.Context, viewer uuid.NullUUID, ids []uuid.UUID) (map[uuid.UUID]*PollJson, error) {
+	polls := map[uuid.UUID]*PollJson{}
+
+	optionsDb, err := a.DbQueries.ListPollOptionsForChirps(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for _, o := range optionsDb {
+		poll, found := polls[o.ChirpID.UUID]
+		if !found {
+			poll = &PollJson{ClosesAt: o.ClosesAt, Closed: !now.Before(o.ClosesAt), Options: []PollOptionJson{}}
+			polls[o.ChirpID.UUID] = poll
+		}
+		poll.Options = append(poll.Options, PollOptionJson{ID: o.ID, Text: o.Body, Votes: o.VoteCount})
+		poll.TotalVotes += o.VoteCount
+	}
+
+	if len(polls) == 0 || !viewer.Valid {
+		return polls, nil
+	}
+
+	votesDb, err := a.DbQueries.ListPollVotesForChirps(ctx, database.ListPollVotesForChirpsParams{
+		UserID:   viewer.UUID,
+		ChirpIds: ids})
+
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range votesDb {
+		if poll, found := polls[v.ChirpID.UUID]; found {
+			poll.VotedFor = &v.OptionID
+		}
+	}
+
+	return polls, nil
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/shahanmmiah/Chirpy/internal/moderation"
+)
+
+func TestParsePollWithoutDatabase(t *testing.T) {
+	a := &ApiConfig{Moderator: moderation.NewFilter(CENSORSTR)}
+	a.Moderator.Replace([]moderation.Rule{
+		{Word: "kerfuffle", Policy: moderation.PolicyCensor},
+		{Word: "scam", Policy: moderation.PolicyHold},
+	}, nil)
+
+	day := int(24 * 60 * 60)
+
+	cases := []struct {
+		Input          PollRequestJson
+		ExpectedErr    bool
+		ExpectedAction moderation.Policy
+	}{
+		{Input: PollRequestJson{Options: []string{"yes", "no"}, ExpiresIn: day}},
+		{Input: PollRequestJson{Options: []string{"a", "b", "c", "d"}, ExpiresIn: day}},
+		{Input: PollRequestJson{Options: []string{"only one"}, ExpiresIn: day}, ExpectedErr: true},
+		{Input: PollRequestJson{Options: []string{"a", "b", "c", "d", "e"}, ExpiresIn: day}, ExpectedErr: true},
+		{Input: PollRequestJson{Options: []string{"yes", " "}, ExpiresIn: day}, ExpectedErr: true},
+		{Input: PollRequestJson{Options: []string{"Yes", "yes"}, ExpiresIn: day}, ExpectedErr: true},
+		{Input: PollRequestJson{Options: []string{"yes", "no"}, ExpiresIn: 10}, ExpectedErr: true},
+		{Input: PollRequestJson{Options: []string{"yes", "no"}, ExpiresIn: 30 * day}, ExpectedErr: true},
+		{Input: PollRequestJson{Options: []string{"yes", strings.Repeat("a", POLLOPTIONLEN+1)}, ExpiresIn: day}, ExpectedErr: true},
+		{Input: PollRequestJson{Options: []string{"a kerfuffle", "no"}, ExpiresIn: day}, ExpectedAction: moderation.PolicyCensor},
+		{Input: PollRequestJson{Options: []string{"a kerfuffle", "a scam"}, ExpiresIn: day}, ExpectedAction: moderation.PolicyHold},
+	}
+
+	for _, c := range cases {
+		poll, err := a.ParsePoll(&c.Input, 0)
+		if (err != nil) != c.ExpectedErr {
+			t.Errorf("ParsePoll(%v) error = %v, expected error: %v", c.Input, err, c.ExpectedErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if poll.Moderation.Action != c.ExpectedAction {
+			t.Errorf("ParsePoll(%v) action = %q, expected %q", c.Input, poll.Moderation.Action, c.ExpectedAction)
+		}
+	}
+
+	poll, err := a.ParsePoll(&PollRequestJson{Options: []string{"a kerfuffle", "no"}, ExpiresIn: day}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if poll.Options[0] != "a "+CENSORSTR {
+		t.Errorf("ParsePoll censored option = %q, expected %q", poll.Options[0], "a "+CENSORSTR)
+	}
+
+	poll, err = a.ParsePoll(nil, 0)
+	if poll != nil || err != nil {
+		t.Errorf("ParsePoll(nil) = %v, %v, expected no poll", poll, err)
+	}
+}
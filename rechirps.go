@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shahanmmiah/Chirpy/internal/database"
+)
+
+const CHIRP_ORIGINAL = "original"
+const CHIRP_RECHIRP = "rechirp"
+const CHIRP_QUOTE = "quote"
+
+// ParseChirpRef validates the kind and referenced chirp of a new chirp. A
+// reference to a rechirp is followed through to the chirp it shares, so
+// rechirps and quotes always point at something with a body. Chirps hidden
+// from userId by a block cannot be shared.
+func (a *ApiConfig) ParseChirpRef(ctx context.Context, userId uuid.UUID, kind, rawRef string) (string, uuid.NullUUID, error) {
+	if kind == "" {
+		kind = CHIRP_ORIGINAL
+	}
+
+	refId, err := ParseOptionalUUID(rawRef)
+	if err != nil {
+		return "", uuid.NullUUID{}, fmt.Errorf("invalid ref_chirp_id: %v", err)
+	}
+
+	switch kind {
+	case CHIRP_ORIGINAL:
+		if refId.Valid {
+			return "", uuid.NullUUID{}, fmt.Errorf("ref_chirp_id is only allowed on a %s or %s", CHIRP_RECHIRP, CHIRP_QUOTE)
+		}
+		return kind, refId, nil
+
+	case CHIRP_RECHIRP, CHIRP_QUOTE:
+		if !refId.Valid {
+			return "", uuid.NullUUID{}, fmt.Errorf("a %s needs a ref_chirp_id", kind)
+		}
+
+	default:
+		return "", uuid.NullUUID{}, fmt.Errorf("unknown chirp_kind %q", kind)
+	}
+
+	refDb, err := a.visibleRef(ctx, userId, refId.UUID)
+	if err != nil {
+		return "", uuid.NullUUID{}, err
+	}
+
+	if refDb.ChirpKind == CHIRP_RECHIRP {
+		if !refDb.RefChirpID.Valid {
+			return "", uuid.NullUUID{}, fmt.Errorf("chirp %v being shared has been deleted", refId.UUID)
+		}
+		refId = refDb.RefChirpID
+
+		// the rechirper may be visible while the original author is not
+		_, err = a.visibleRef(ctx, userId, refId.UUID)
+		if err != nil {
+			return "", uuid.NullUUID{}, err
+		}
+	}
+
+	return kind, refId, nil
+}
+
+func (a *ApiConfig) visibleRef(ctx context.Context, userId, refId uuid.UUID) (database.Chirp, error) {
+	refDb, err := a.DbQueries.GetVisibleChirp(ctx, database.GetVisibleChirpParams{
+		ID:       refId,
+		ViewerID: uuid.NullUUID{UUID: userId, Valid: true}})
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return database.Chirp{}, fmt.Errorf("chirp %v being shared not found", refId)
+	}
+	return refDb, err
+}
+
+// CreateRechirp shares refId as-is. A rechirp has no body of its own, so it
+// skips the length and moderation checks that quotes go through.
+func (a *ApiConfig) CreateRechirp(resp http.ResponseWriter, req *http.Request, userId uuid.UUID, refId uuid.NullUUID) {
+	tx, err := a.Db.BeginTx(req.Context(), nil)
+	if err != nil {
+		ErrorJsonResp(resp, err, FAILEDCODE)
+		return
+	}
+	defer tx.Rollback()
+
+	queries := a.DbQueries.WithTx(tx)
+
+	chirpDb, err := queries.CreateChirps(req.Context(), database.CreateChirpsParams{
+		ID:         uuid.New(),
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+		Body:       "",
+		UserID:     userId,
+		ChirpKind:  CHIRP_RECHIRP,
+		RefChirpID: refId,
+		Status:     CHIRP_PUBLISHED})
+
+	if IsUniqueViolation(err) {
+		ErrorJsonResp(resp, fmt.Errorf("chirp %v already rechirped", refId.UUID), CONFLICTCODE)
+		return
+	}
+	if err != nil {
+		ErrorJsonResp(resp, err, FAILEDCODE)
+		return
+	}
+
+	err = a.Fanout.Chirp(req.Context(), queries, chirpDb.ID)
+	if err != nil {
+		ErrorJsonResp(resp, err, FAILEDCODE)
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		ErrorJsonResp(resp, err, FAILEDCODE)
+		return
+	}
+
+	a.Fanout.Wake()
+	a.ChirpResp(resp, req, chirpDb, NEWCODE)
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestParseChirpRefWithoutDatabase(t *testing.T) {
+	a := &ApiConfig{}
+
+	cases := []struct {
+		InputKind   string
+		InputRef    string
+		ExpectedErr bool
+	}{
+		{InputKind: "", InputRef: "", ExpectedErr: false},
+		{InputKind: CHIRP_ORIGINAL, InputRef: "", ExpectedErr: false},
+		{InputKind: CHIRP_ORIGINAL, InputRef: "3311741c-680c-4546-99f3-fc9efac2036c", ExpectedErr: true},
+		{InputKind: CHIRP_RECHIRP, InputRef: "", ExpectedErr: true},
+		{InputKind: CHIRP_QUOTE, InputRef: "", ExpectedErr: true},
+		{InputKind: CHIRP_QUOTE, InputRef: "not-a-uuid", ExpectedErr: true},
+		{InputKind: "reply", InputRef: "3311741c-680c-4546-99f3-fc9efac2036c", ExpectedErr: true},
+	}
+
+	for _, c := range cases {
+		kind, ref, err := a.ParseChirpRef(context.Background(), uuid.New(), c.InputKind, c.InputRef)
+		if (err != nil) != c.ExpectedErr {
+			t.Errorf("ParseChirpRef(%q, %q) error = %v, expected error: %v", c.InputKind, c.InputRef, err, c.ExpectedErr)
+			continue
+		}
+		if err == nil && (kind != CHIRP_ORIGINAL || ref.Valid) {
+			t.Errorf("ParseChirpRef(%q, %q) = %q, %v, expected an original with no reference", c.InputKind, c.InputRef, kind, ref)
+		}
+	}
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shahanmmiah/Chirpy/internal/database"
+)
+
+// RevisionJson is an earlier body of an edited chirp. CreatedAt is when that
+// body was posted or last edited in, ReplacedAt when an edit replaced it.
+type RevisionJson struct {
+	Body       string    `json:"body"`
+	CreatedAt  time.Time `json:"created_at"`
+	ReplacedAt time.Time `json:"replaced_at"`
+}
+
+// MiddlewareEditChirp replaces the body of one of the caller's chirps, for up
+// to window after it was published. The new body goes through the same
+// length and moderation checks as a new chirp, and its tags, mentions and
+// link preview are worked out again. The old body is kept as a revision.
+func (a *ApiConfig) MiddlewareEditChirp(limits ChirpLimits, window time.Duration) http.Handler {
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		userId, err := a.AuthenticatedUser(req)
+		if err != nil {
+			ErrorJsonResp(resp, err, UNAUTHORIZED)
+			return
+		}
+
+		id, err := uuid.Parse(req.PathValue("chirpID"))
+		if err != nil {
+			ErrorJsonResp(resp, fmt.Errorf("invalid chirp id: %v", err), FAILEDCODE)
+			return
+		}
+
+		resData := struct {
+			Body string `json:"body"`
+		}{}
+
+		reqData, err := io.ReadAll(req.Body)
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		err = json.Unmarshal(reqData, &resData)
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		tx, err := a.Db.BeginTx(req.Context(), nil)
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+		defer tx.Rollback()
+
+		queries := a.DbQueries.WithTx(tx)
+
+		// the row stays locked until commit, so concurrent edits each keep the
+		// body they replaced
+		chirpDb, err := queries.GetChirpForEdit(req.Context(), id)
+		if errors.Is(err, sql.ErrNoRows) {
+			ErrorJsonResp(resp, fmt.Errorf("chirp %v not found", id), NOTFOUNDCODE)
+			return
+		}
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		now := time.Now()
+		code, err := CheckEditable(chirpDb, userId, now, window)
+		if err != nil {
+			ErrorJsonResp(resp, err, code)
+			return
+		}
+
+		if !a.CheckChirpLength(resp, req, userId, resData.Body, limits) {
+			return
+		}
+
+		body, changed, err := a.EditedBody(chirpDb, resData.Body)
+		if err != nil {
+			ErrorJsonResp(resp, err, UNPROCESSABLECODE)
+			return
+		}
+		if !changed {
+			a.ChirpResp(resp, req, chirpDb, OKCODE)
+			return
+		}
+
+		since := chirpDb.CreatedAt
+		if chirpDb.EditedAt.Valid {
+			since = chirpDb.EditedAt.Time
+		}
+
+		err = queries.CreateChirpRevision(req.Context(), database.CreateChirpRevisionParams{
+			ID:         uuid.New(),
+			ChirpID:    chirpDb.ID,
+			Body:       chirpDb.Body,
+			CreatedAt:  since,
+			ReplacedAt: now})
+
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		chirpDb, err = queries.EditChirp(req.Context(), database.EditChirpParams{
+			Body:     body,
+			EditedAt: now,
+			ID:       chirpDb.ID})
+
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		err = queries.DeleteChirpTags(req.Context(), chirpDb.ID)
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		err = queries.DeleteChirpMentions(req.Context(), chirpDb.ID)
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		err = StoreChirpEntities(req.Context(), queries, chirpDb)
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		// the first link may have changed, the worker fetches it again
+		err = queries.DeleteLinkPreview(req.Context(), chirpDb.ID)
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		err = tx.Commit()
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		a.Previews.Chirp(chirpDb.ID, chirpDb.Body)
+		a.ChirpResp(resp, req, chirpDb, OKCODE)
+	})
+}
+
+// CheckEditable refuses an edit by userId at now that the chirp cannot take,
+// returning the status code to send with the error. Only the author can edit
+// and only for window after posting, and a rechirp has no body of its own.
+func CheckEditable(chirpDb database.Chirp, userId uuid.UUID, now time.Time, window time.Duration) (int, error) {
+	if chirpDb.UserID != userId {
+		return FORBIDDENCODE, fmt.Errorf("only the author can edit this chirp")
+	}
+
+	if chirpDb.ChirpKind == CHIRP_RECHIRP {
+		return FAILEDCODE, fmt.Errorf("a %s has no body to edit", CHIRP_RECHIRP)
+	}
+
+	if now.Sub(chirpDb.CreatedAt) > window {
+		return FORBIDDENCODE, fmt.Errorf("chirps can only be edited for %v after posting", window)
+	}
+
+	return OKCODE, nil
+}
+
+// EditedBody moderates the new body of an edit, returning it censored and
+// whether it differs from the chirp's current body. An edit cannot be held
+// for review without taking down the chirp people have already seen, so
+// anything that would hold is refused.
+func (a *ApiConfig) EditedBody(chirpDb database.Chirp, body string) (string, bool, error) {
+	moderated, err := a.CheckWithoutHold(body)
+	if err != nil {
+		return "", false, err
+	}
+	return moderated.Text, moderated.Text != chirpDb.Body, nil
+}
+
+// MiddlewareGetChirpHistory lists the earlier bodies of a chirp, oldest
+// first. The current body is the chirp itself.
+func (a *ApiConfig) MiddlewareGetChirpHistory() http.Handler {
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		id, err := uuid.Parse(req.PathValue("chirpID"))
+		if err != nil {
+			ErrorJsonResp(resp, fmt.Errorf("invalid chirp id: %v", err), FAILEDCODE)
+			return
+		}
+
+		_, err = a.DbQueries.GetVisibleChirp(req.Context(), database.GetVisibleChirpParams{
+			ID:       id,
+			ViewerID: a.Viewer(req)})
+
+		if errors.Is(err, sql.ErrNoRows) {
+			ErrorJsonResp(resp, fmt.Errorf("chirp %v not found", id), NOTFOUNDCODE)
+			return
+		}
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		revisionsDb, err := a.DbQueries.ListChirpRevisions(req.Context(), id)
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		revisionsJson := []RevisionJson{}
+		for _, r := range revisionsDb {
+			revisionsJson = append(revisionsJson, RevisionJson{
+				Body:       r.Body,
+				CreatedAt:  r.CreatedAt,
+				ReplacedAt: r.ReplacedAt})
+		}
+
+		jsonData, err := json.Marshal(revisionsJson)
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		resp.Header().Set("Content-Type", "application/json")
+		resp.WriteHeader(OKCODE)
+		resp.Write(jsonData)
+	})
+}
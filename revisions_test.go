@@ -0,0 +1,75 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shahanmmiah/Chirpy/internal/database"
+	"github.com/shahanmmiah/Chirpy/internal/moderation"
+)
+
+func TestEditChecks(t *testing.T) {
+	a := &ApiConfig{Moderator: moderation.NewFilter(CENSORSTR)}
+	a.Moderator.Replace([]moderation.Rule{
+		{Word: "kerfuffle", Policy: moderation.PolicyCensor},
+		{Word: "scam", Policy: moderation.PolicyHold},
+		{Word: "slur", Policy: moderation.PolicyReject},
+	}, nil)
+
+	author := uuid.New()
+	posted := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	window := 15 * time.Minute
+
+	original := database.Chirp{ID: uuid.New(), UserID: author, Body: "hello there", ChirpKind: CHIRP_ORIGINAL, CreatedAt: posted}
+	rechirp := database.Chirp{ID: uuid.New(), UserID: author, ChirpKind: CHIRP_RECHIRP, CreatedAt: posted}
+
+	cases := []struct {
+		Name            string
+		Chirp           database.Chirp
+		UserID          uuid.UUID
+		Body            string
+		After           time.Duration
+		ExpectedCode    int
+		ExpectedBody    string
+		ExpectedChanged bool
+	}{
+		{Name: "edit", Chirp: original, UserID: author, Body: "hello world", After: time.Minute, ExpectedCode: OKCODE, ExpectedBody: "hello world", ExpectedChanged: true},
+		{Name: "last moment of the window", Chirp: original, UserID: author, Body: "hello world", After: window, ExpectedCode: OKCODE, ExpectedBody: "hello world", ExpectedChanged: true},
+		{Name: "just past the window", Chirp: original, UserID: author, Body: "hello world", After: window + time.Nanosecond, ExpectedCode: FORBIDDENCODE},
+		{Name: "not the author", Chirp: original, UserID: uuid.New(), Body: "hello world", After: time.Minute, ExpectedCode: FORBIDDENCODE},
+		{Name: "rechirp", Chirp: rechirp, UserID: author, Body: "hello world", After: time.Minute, ExpectedCode: FAILEDCODE},
+		{Name: "same body", Chirp: original, UserID: author, Body: "hello there", After: time.Minute, ExpectedCode: OKCODE, ExpectedBody: "hello there"},
+		{Name: "censored", Chirp: original, UserID: author, Body: "what a kerfuffle", After: time.Minute, ExpectedCode: OKCODE, ExpectedBody: "what a " + CENSORSTR, ExpectedChanged: true},
+		{Name: "would be held", Chirp: original, UserID: author, Body: "a total scam", After: time.Minute, ExpectedCode: UNPROCESSABLECODE},
+		{Name: "rejected", Chirp: original, UserID: author, Body: "a slur", After: time.Minute, ExpectedCode: UNPROCESSABLECODE},
+		// the chirp is refused before its new body is looked at
+		{Name: "held body past the window", Chirp: original, UserID: author, Body: "a total scam", After: time.Hour, ExpectedCode: FORBIDDENCODE},
+	}
+
+	for _, c := range cases {
+		code, err := CheckEditable(c.Chirp, c.UserID, posted.Add(c.After), window)
+		if err != nil {
+			if code != c.ExpectedCode {
+				t.Errorf("%s: CheckEditable code = %d (%v), expected %d", c.Name, code, err, c.ExpectedCode)
+			}
+			continue
+		}
+
+		body, changed, err := a.EditedBody(c.Chirp, c.Body)
+		if err != nil {
+			code = UNPROCESSABLECODE
+		}
+		if code != c.ExpectedCode {
+			t.Errorf("%s: code = %d (%v), expected %d", c.Name, code, err, c.ExpectedCode)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+
+		if body != c.ExpectedBody || changed != c.ExpectedChanged {
+			t.Errorf("%s: EditedBody = %q, %v, expected %q, %v", c.Name, body, changed, c.ExpectedBody, c.ExpectedChanged)
+		}
+	}
+}
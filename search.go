@@ -0,0 +1,100 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/shahanmmiah/Chirpy/internal/database"
+	"github.com/shahanmmiah/Chirpy/internal/pagination"
+	"github.com/shahanmmiah/Chirpy/internal/search"
+)
+
+// SearchResultJson is a chirp matching a search. Headline is HTML: the body
+// with the matched words wrapped in <mark>, and everything else escaped, so
+// it is safe to render as markup.
+type SearchResultJson struct {
+	ChirpJson
+	Rank     float32 `json:"rank"`
+	Headline string  `json:"headline"`
+}
+
+func (a *ApiConfig) MiddlewareSearchChirps() http.Handler {
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		query, err := search.ToTSQuery(req.URL.Query().Get("q"))
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		limit, err := pagination.LimitFromQuery(req.URL.Query())
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		authorId, err := ParseOptionalUUID(req.URL.Query().Get("author_id"))
+		if err != nil {
+			ErrorJsonResp(resp, fmt.Errorf("invalid author_id: %v", err), FAILEDCODE)
+			return
+		}
+
+		params := database.SearchChirpsParams{
+			Query:    query,
+			AuthorID: authorId,
+			ViewerID: a.Viewer(req),
+			RowLimit: limit + 1}
+
+		if rawCursor := req.URL.Query().Get("cursor"); rawCursor != "" {
+			cursor, err := search.DecodeCursor(rawCursor)
+			if err != nil {
+				ErrorJsonResp(resp, err, FAILEDCODE)
+				return
+			}
+			params.CursorRank = sql.NullFloat64{Float64: float64(cursor.Rank), Valid: true}
+			params.CursorID.UUID, params.CursorID.Valid = cursor.ID, true
+		}
+
+		resultsDb, err := a.DbQueries.SearchChirps(req.Context(), params)
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		if len(resultsDb) > int(limit) {
+			resultsDb = resultsDb[:limit]
+			last := resultsDb[len(resultsDb)-1]
+			resp.Header().Set("Link", pagination.NextLink(req.URL, search.Cursor{Rank: last.Rank, ID: last.Chirp.ID}.Encode()))
+		}
+
+		chirpsDb := []database.Chirp{}
+		for _, r := range resultsDb {
+			chirpsDb = append(chirpsDb, r.Chirp)
+		}
+
+		chirpsJson, err := a.ChirpsToJson(req.Context(), a.Viewer(req), chirpsDb)
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		resultsJson := []SearchResultJson{}
+		for i, r := range resultsDb {
+			resultsJson = append(resultsJson, SearchResultJson{
+				ChirpJson: chirpsJson[i],
+				Rank:      r.Rank,
+				Headline:  r.Headline})
+		}
+
+		jsonData, err := json.Marshal(resultsJson)
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		resp.Header().Set("Content-type", "application/json")
+		resp.WriteHeader(OKCODE)
+		resp.Write(jsonData)
+	})
+}
@@ -0,0 +1,46 @@
+-- name: BlockUser :execrows
+INSERT INTO blocks(blocker_id, blocked_id, created_at)
+VALUES (
+    $1,
+    $2,
+    $3
+)
+ON CONFLICT DO NOTHING;
+
+-- name: MuteUser :execrows
+INSERT INTO mutes(muter_id, muted_id, created_at)
+VALUES (
+    $1,
+    $2,
+    $3
+)
+ON CONFLICT DO NOTHING;
+
+-- name: UnblockUser :execrows
+DELETE FROM blocks WHERE blocker_id = $1 AND blocked_id = $2;
+
+-- name: UnmuteUser :execrows
+DELETE FROM mutes WHERE muter_id = $1 AND muted_id = $2;
+
+-- name: IsBlockedBetween :one
+SELECT is_blocked_between(sqlc.arg(user_a), sqlc.arg(user_b));
+
+-- name: ListBlocks :many
+SELECT sqlc.embed(users), blocks.created_at AS blocked_at FROM blocks
+JOIN users ON users.id = blocks.blocked_id
+WHERE blocks.blocker_id = sqlc.arg(user_id)
+AND users.deleted_at IS NULL
+AND (sqlc.narg(cursor_created_at)::TIMESTAMP IS NULL
+    OR (blocks.created_at, blocks.blocked_id) < (sqlc.narg(cursor_created_at)::TIMESTAMP, sqlc.narg(cursor_id)::UUID))
+ORDER BY blocks.created_at DESC, blocks.blocked_id DESC
+LIMIT sqlc.arg(row_limit);
+
+-- name: ListMutes :many
+SELECT sqlc.embed(users), mutes.created_at AS muted_at FROM mutes
+JOIN users ON users.id = mutes.muted_id
+WHERE mutes.muter_id = sqlc.arg(user_id)
+AND users.deleted_at IS NULL
+AND (sqlc.narg(cursor_created_at)::TIMESTAMP IS NULL
+    OR (mutes.created_at, mutes.muted_id) < (sqlc.narg(cursor_created_at)::TIMESTAMP, sqlc.narg(cursor_id)::UUID))
+ORDER BY mutes.created_at DESC, mutes.muted_id DESC
+LIMIT sqlc.arg(row_limit);
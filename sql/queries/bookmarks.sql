@@ -0,0 +1,26 @@
+-- name: AddBookmark :execrows
+INSERT INTO bookmarks(user_id, chirp_id, created_at)
+VALUES (
+    $1,
+    $2,
+    $3
+)
+ON CONFLICT DO NOTHING;
+
+-- name: RemoveBookmark :execrows
+DELETE FROM bookmarks WHERE user_id = $1 AND chirp_id = $2;
+
+-- name: GetBookmarkedChirps :many
+SELECT chirp_id FROM bookmarks
+WHERE user_id = sqlc.arg(user_id) AND chirp_id = ANY(sqlc.arg(chirp_ids)::UUID[]);
+
+-- name: ListBookmarkChirps :many
+SELECT sqlc.embed(chirps), bookmarks.created_at AS bookmarked_at FROM chirps
+JOIN bookmarks ON bookmarks.chirp_id = chirps.id
+WHERE bookmarks.user_id = sqlc.arg(user_id)
+AND chirps.status = 'published' AND chirps.deleted_at IS NULL
+AND NOT is_blocked_between(sqlc.arg(user_id)::UUID, chirps.user_id)
+AND (sqlc.narg(cursor_created_at)::TIMESTAMP IS NULL
+    OR (bookmarks.created_at, bookmarks.chirp_id) < (sqlc.narg(cursor_created_at)::TIMESTAMP, sqlc.narg(cursor_id)::UUID))
+ORDER BY bookmarks.created_at DESC, bookmarks.chirp_id DESC
+LIMIT sqlc.arg(row_limit);
@@ -1,11 +1,16 @@
 -- name: CreateChirps :one
-INSERT INTO chirps(id, created_at, updated_at, body, user_id)
+INSERT INTO chirps(id, created_at, updated_at, body, user_id, in_reply_to, chirp_kind, ref_chirp_id, status, publish_at)
 VALUES (
     $1,
     $2,
     $3,
     $4,
-    $5
+    $5,
+    $6,
+    $7,
+    $8,
+    $9,
+    $10
 )
 RETURNING *;
 
@@ -13,7 +18,92 @@ RETURNING *;
 DELETE FROM chirps;
 
 -- name: GetAllChirps :many
-SELECT * FROM chirps ORDER BY created_at ASC;
+SELECT * FROM chirps WHERE status = 'published' AND deleted_at IS NULL ORDER BY created_at ASC;
 
 -- name: GetChirps :one
-SELECT * FROM chirps WHERE id = $1 LIMIT 1;
\ No newline at end of file
+SELECT * FROM chirps WHERE id = $1 LIMIT 1;
+
+-- name: DeleteChirp :exec
+DELETE FROM chirps WHERE id = $1 AND user_id = $2;
+
+-- name: GetChirpsByIds :many
+SELECT * FROM chirps
+WHERE id = ANY(sqlc.arg(ids)::UUID[])
+AND status = 'published' AND deleted_at IS NULL
+AND NOT is_blocked_between(sqlc.narg(viewer_id)::UUID, chirps.user_id);
+
+-- name: ListDeletedChirpIds :many
+SELECT id FROM chirps
+WHERE id = ANY(sqlc.arg(ids)::UUID[])
+AND deleted_at IS NOT NULL
+AND NOT is_blocked_between(sqlc.narg(viewer_id)::UUID, chirps.user_id);
+
+-- name: ListChirpsAsc :many
+SELECT * FROM chirps
+WHERE (sqlc.narg(author_id)::UUID IS NULL OR user_id = sqlc.narg(author_id)::UUID)
+AND status = 'published' AND deleted_at IS NULL
+AND NOT is_blocked_between(sqlc.narg(viewer_id)::UUID, chirps.user_id)
+AND NOT EXISTS (
+    SELECT 1 FROM mutes
+    WHERE mutes.muter_id = sqlc.narg(viewer_id)::UUID AND mutes.muted_id = chirps.user_id)
+AND (sqlc.narg(cursor_created_at)::TIMESTAMP IS NULL
+    OR (created_at, id) > (sqlc.narg(cursor_created_at)::TIMESTAMP, sqlc.narg(cursor_id)::UUID))
+ORDER BY created_at ASC, id ASC
+LIMIT sqlc.arg(row_limit);
+
+-- name: ListChirpsDesc :many
+SELECT * FROM chirps
+WHERE (sqlc.narg(author_id)::UUID IS NULL OR user_id = sqlc.narg(author_id)::UUID)
+AND status = 'published' AND deleted_at IS NULL
+AND NOT is_blocked_between(sqlc.narg(viewer_id)::UUID, chirps.user_id)
+AND NOT EXISTS (
+    SELECT 1 FROM mutes
+    WHERE mutes.muter_id = sqlc.narg(viewer_id)::UUID AND mutes.muted_id = chirps.user_id)
+AND (sqlc.narg(cursor_created_at)::TIMESTAMP IS NULL
+    OR (created_at, id) < (sqlc.narg(cursor_created_at)::TIMESTAMP, sqlc.narg(cursor_id)::UUID))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(row_limit);
+
+-- name: SearchChirps :many
+SELECT sqlc.embed(chirps),
+    ts_rank(chirps.body_tsv, query)::REAL AS rank,
+    ts_headline('english',
+        replace(replace(replace(replace(chirps.body, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), '"', '&quot;'),
+        query, 'StartSel=<mark>, StopSel=</mark>, HighlightAll=true')::TEXT AS headline
+FROM chirps, to_tsquery('english', sqlc.arg(query)::TEXT) query
+WHERE chirps.body_tsv @@ query
+AND chirps.status = 'published' AND chirps.deleted_at IS NULL
+AND (sqlc.narg(author_id)::UUID IS NULL OR chirps.user_id = sqlc.narg(author_id)::UUID)
+AND NOT is_blocked_between(sqlc.narg(viewer_id)::UUID, chirps.user_id)
+AND (sqlc.narg(cursor_rank)::REAL IS NULL
+    OR (ts_rank(chirps.body_tsv, query)::REAL, chirps.id) < (sqlc.narg(cursor_rank)::REAL, sqlc.narg(cursor_id)::UUID))
+ORDER BY rank DESC, chirps.id DESC
+LIMIT sqlc.arg(row_limit);
+
+-- name: GetVisibleChirp :one
+SELECT * FROM chirps
+WHERE id = sqlc.arg(id)
+AND status = 'published' AND deleted_at IS NULL
+AND NOT is_blocked_between(sqlc.narg(viewer_id)::UUID, chirps.user_id)
+LIMIT 1;
+
+-- name: SoftDeleteChirp :execrows
+UPDATE chirps
+SET deleted_at = sqlc.arg(deleted_at)
+WHERE id = sqlc.arg(id) AND user_id = sqlc.arg(user_id) AND deleted_at IS NULL;
+
+-- name: SoftDeleteRechirpsOf :exec
+UPDATE chirps
+SET deleted_at = sqlc.arg(deleted_at)
+WHERE ref_chirp_id = sqlc.arg(ref_chirp_id) AND chirp_kind = 'rechirp' AND deleted_at IS NULL;
+
+-- name: RestoreChirp :one
+UPDATE chirps
+SET deleted_at = NULL
+WHERE id = sqlc.arg(id) AND user_id = sqlc.arg(user_id) AND deleted_at >= sqlc.arg(deleted_since)
+RETURNING *;
+
+-- name: RestoreRechirpsOf :exec
+UPDATE chirps
+SET deleted_at = NULL
+WHERE ref_chirp_id = sqlc.arg(ref_chirp_id) AND chirp_kind = 'rechirp' AND deleted_at = sqlc.arg(deleted_at);
@@ -0,0 +1,53 @@
+-- name: ListDrafts :many
+SELECT * FROM chirps
+WHERE user_id = sqlc.arg(user_id)
+AND status <> 'published'
+AND deleted_at IS NULL
+AND (sqlc.narg(cursor_created_at)::TIMESTAMP IS NULL
+    OR (created_at, id) < (sqlc.narg(cursor_created_at)::TIMESTAMP, sqlc.narg(cursor_id)::UUID))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(row_limit);
+
+-- name: GetDraft :one
+SELECT * FROM chirps
+WHERE id = sqlc.arg(id) AND user_id = sqlc.arg(user_id) AND status <> 'published' AND deleted_at IS NULL;
+
+-- name: UpdateDraft :one
+UPDATE chirps
+SET body = sqlc.arg(body),
+    status = sqlc.arg(status),
+    publish_at = sqlc.narg(publish_at),
+    updated_at = sqlc.arg(updated_at),
+    created_at = CASE WHEN sqlc.arg(status) = 'published' THEN sqlc.arg(updated_at) ELSE created_at END
+WHERE id = sqlc.arg(id) AND user_id = sqlc.arg(user_id) AND status <> 'published' AND deleted_at IS NULL
+RETURNING *;
+
+-- name: DeleteDraft :execrows
+DELETE FROM chirps
+WHERE id = sqlc.arg(id) AND user_id = sqlc.arg(user_id) AND status <> 'published' AND deleted_at IS NULL;
+
+-- name: PublishDueChirps :many
+WITH due AS (
+    SELECT id FROM chirps
+    WHERE status = 'scheduled' AND publish_at <= sqlc.arg(now)
+    AND deleted_at IS NULL
+    ORDER BY publish_at
+    LIMIT sqlc.arg(row_limit)
+    FOR UPDATE SKIP LOCKED
+)
+UPDATE chirps
+SET status = 'published', created_at = sqlc.arg(now), updated_at = sqlc.arg(now)
+FROM due
+WHERE chirps.id = due.id
+RETURNING chirps.*;
+
+-- name: ReturnToDraft :exec
+UPDATE chirps
+SET status = 'draft', publish_at = NULL, updated_at = sqlc.arg(updated_at)
+WHERE id = sqlc.arg(id);
+
+-- name: CensorChirp :one
+UPDATE chirps
+SET body = sqlc.arg(body)
+WHERE id = sqlc.arg(id)
+RETURNING *;
@@ -0,0 +1,25 @@
+-- name: EnqueueFanoutJob :exec
+INSERT INTO fanout_jobs(kind, chirp_id, follower_id, followee_id, run_after, created_at)
+VALUES (
+    sqlc.arg(kind),
+    sqlc.narg(chirp_id),
+    sqlc.narg(follower_id),
+    sqlc.narg(followee_id),
+    sqlc.arg(created_at),
+    sqlc.arg(created_at)
+);
+
+-- name: ClaimFanoutJob :one
+SELECT * FROM fanout_jobs
+WHERE run_after <= sqlc.arg(now)
+ORDER BY id
+LIMIT 1
+FOR UPDATE SKIP LOCKED;
+
+-- name: DeleteFanoutJob :exec
+DELETE FROM fanout_jobs WHERE id = $1;
+
+-- name: RetryFanoutJob :exec
+UPDATE fanout_jobs
+SET attempts = attempts + 1, run_after = sqlc.arg(run_after)
+WHERE id = sqlc.arg(id);
@@ -0,0 +1,65 @@
+-- name: FollowUser :execrows
+INSERT INTO follows(follower_id, followee_id, created_at)
+VALUES (
+    $1,
+    $2,
+    $3
+)
+ON CONFLICT DO NOTHING;
+
+-- name: UnfollowUser :execrows
+DELETE FROM follows WHERE follower_id = $1 AND followee_id = $2;
+
+-- name: IsFollowing :one
+SELECT EXISTS (
+    SELECT 1 FROM follows WHERE follower_id = $1 AND followee_id = $2
+);
+
+-- name: GetFollowCounts :one
+SELECT
+    (SELECT COUNT(*) FROM follows JOIN users ON users.id = follows.follower_id
+        WHERE follows.followee_id = sqlc.arg(user_id) AND users.deleted_at IS NULL)::INT AS followers,
+    (SELECT COUNT(*) FROM follows JOIN users ON users.id = follows.followee_id
+        WHERE follows.follower_id = sqlc.arg(user_id) AND users.deleted_at IS NULL)::INT AS following;
+
+-- name: ListFollowers :many
+SELECT sqlc.embed(users), follows.created_at AS followed_at FROM follows
+JOIN users ON users.id = follows.follower_id
+WHERE follows.followee_id = sqlc.arg(user_id)
+AND users.deleted_at IS NULL
+AND NOT is_blocked_between(sqlc.narg(viewer_id)::UUID, users.id)
+AND (sqlc.narg(cursor_created_at)::TIMESTAMP IS NULL
+    OR (follows.created_at, follows.follower_id) < (sqlc.narg(cursor_created_at)::TIMESTAMP, sqlc.narg(cursor_id)::UUID))
+ORDER BY follows.created_at DESC, follows.follower_id DESC
+LIMIT sqlc.arg(row_limit);
+
+-- name: ListFollowing :many
+SELECT sqlc.embed(users), follows.created_at AS followed_at FROM follows
+JOIN users ON users.id = follows.followee_id
+WHERE follows.follower_id = sqlc.arg(user_id)
+AND users.deleted_at IS NULL
+AND NOT is_blocked_between(sqlc.narg(viewer_id)::UUID, users.id)
+AND (sqlc.narg(cursor_created_at)::TIMESTAMP IS NULL
+    OR (follows.created_at, follows.followee_id) < (sqlc.narg(cursor_created_at)::TIMESTAMP, sqlc.narg(cursor_id)::UUID))
+ORDER BY follows.created_at DESC, follows.followee_id DESC
+LIMIT sqlc.arg(row_limit);
+
+-- name: ListHomeTimeline :many
+SELECT chirps.* FROM chirps
+WHERE (chirps.user_id = sqlc.arg(user_id)
+    OR chirps.user_id IN (SELECT followee_id FROM follows WHERE follower_id = sqlc.arg(user_id)))
+AND chirps.status = 'published' AND chirps.deleted_at IS NULL
+AND NOT is_blocked_between(sqlc.arg(user_id)::UUID, chirps.user_id)
+AND NOT EXISTS (
+    SELECT 1 FROM mutes
+    WHERE mutes.muter_id = sqlc.arg(user_id)::UUID AND mutes.muted_id = chirps.user_id)
+AND (sqlc.narg(cursor_created_at)::TIMESTAMP IS NULL
+    OR (chirps.created_at, chirps.id) < (sqlc.narg(cursor_created_at)::TIMESTAMP, sqlc.narg(cursor_id)::UUID))
+ORDER BY chirps.created_at DESC, chirps.id DESC
+LIMIT sqlc.arg(row_limit);
+
+-- name: AdjustFollowerCount :one
+UPDATE users
+SET follower_count = follower_count + sqlc.arg(delta)::INT
+WHERE id = sqlc.arg(id)
+RETURNING follower_count;
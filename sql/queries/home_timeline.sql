@@ -0,0 +1,79 @@
+-- name: FanOutChirp :execrows
+INSERT INTO home_timeline(user_id, chirp_id, created_at)
+SELECT chirps.user_id, chirps.id, chirps.created_at FROM chirps
+WHERE chirps.id = sqlc.arg(chirp_id) AND chirps.status = 'published' AND chirps.deleted_at IS NULL
+UNION ALL
+SELECT follows.follower_id, chirps.id, chirps.created_at FROM chirps
+JOIN users ON users.id = chirps.user_id
+JOIN follows ON follows.followee_id = chirps.user_id
+WHERE chirps.id = sqlc.arg(chirp_id) AND chirps.status = 'published' AND chirps.deleted_at IS NULL AND users.follower_count <= sqlc.arg(fanout_limit)::INT
+ON CONFLICT DO NOTHING;
+
+-- name: BackfillHomeTimeline :execrows
+INSERT INTO home_timeline(user_id, chirp_id, created_at)
+SELECT sqlc.arg(user_id)::UUID, recent.id, recent.created_at FROM (
+    SELECT chirps.id, chirps.created_at FROM chirps
+    JOIN users ON users.id = chirps.user_id
+    WHERE chirps.user_id = sqlc.arg(followee_id) AND users.follower_count <= sqlc.arg(fanout_limit)::INT
+    AND chirps.status = 'published' AND chirps.deleted_at IS NULL
+    AND EXISTS (
+        SELECT 1 FROM follows
+        WHERE follows.follower_id = sqlc.arg(user_id)::UUID AND follows.followee_id = chirps.user_id)
+    ORDER BY chirps.created_at DESC
+    LIMIT sqlc.arg(row_limit)
+) recent
+ON CONFLICT DO NOTHING;
+
+-- name: RemoveFolloweeFromHomeTimeline :execrows
+DELETE FROM home_timeline
+USING chirps
+WHERE home_timeline.chirp_id = chirps.id
+AND home_timeline.user_id = sqlc.arg(user_id) AND chirps.user_id = sqlc.arg(followee_id)
+AND NOT EXISTS (
+    SELECT 1 FROM follows
+    WHERE follows.follower_id = sqlc.arg(user_id) AND follows.followee_id = sqlc.arg(followee_id));
+
+-- name: ListMaterialisedHomeTimeline :many
+SELECT chirps.* FROM chirps
+WHERE chirps.id IN (
+    (SELECT home_timeline.chirp_id FROM home_timeline
+    JOIN chirps stored ON stored.id = home_timeline.chirp_id
+    WHERE home_timeline.user_id = sqlc.arg(user_id) AND stored.deleted_at IS NULL
+    AND NOT is_blocked_between(sqlc.arg(user_id)::UUID, stored.user_id)
+    AND NOT EXISTS (
+        SELECT 1 FROM mutes
+        WHERE mutes.muter_id = sqlc.arg(user_id)::UUID AND mutes.muted_id = stored.user_id)
+    AND (sqlc.narg(cursor_created_at)::TIMESTAMP IS NULL
+        OR (home_timeline.created_at, home_timeline.chirp_id) < (sqlc.narg(cursor_created_at)::TIMESTAMP, sqlc.narg(cursor_id)::UUID))
+    ORDER BY home_timeline.created_at DESC, home_timeline.chirp_id DESC
+    LIMIT sqlc.arg(row_limit))
+    UNION ALL
+    (SELECT pulled.id FROM chirps pulled
+    JOIN follows ON follows.followee_id = pulled.user_id
+    JOIN users ON users.id = follows.followee_id
+    WHERE follows.follower_id = sqlc.arg(user_id) AND users.follower_count > sqlc.arg(fanout_limit)::INT
+    AND pulled.status = 'published' AND pulled.deleted_at IS NULL
+    AND NOT is_blocked_between(sqlc.arg(user_id)::UUID, pulled.user_id)
+    AND NOT EXISTS (
+        SELECT 1 FROM mutes
+        WHERE mutes.muter_id = sqlc.arg(user_id)::UUID AND mutes.muted_id = pulled.user_id)
+    AND (sqlc.narg(cursor_created_at)::TIMESTAMP IS NULL
+        OR (pulled.created_at, pulled.id) < (sqlc.narg(cursor_created_at)::TIMESTAMP, sqlc.narg(cursor_id)::UUID))
+    ORDER BY pulled.created_at DESC, pulled.id DESC
+    LIMIT sqlc.arg(row_limit))
+)
+ORDER BY chirps.created_at DESC, chirps.id DESC
+LIMIT sqlc.arg(row_limit);
+
+-- name: BackfillFollowerTimelines :execrows
+INSERT INTO home_timeline(user_id, chirp_id, created_at)
+SELECT follows.follower_id, recent.id, recent.created_at FROM (
+    SELECT chirps.id, chirps.created_at FROM chirps
+    JOIN users ON users.id = chirps.user_id
+    WHERE chirps.user_id = sqlc.arg(author_id) AND users.follower_count <= sqlc.arg(fanout_limit)::INT
+    AND chirps.status = 'published' AND chirps.deleted_at IS NULL
+    ORDER BY chirps.created_at DESC
+    LIMIT sqlc.arg(row_limit)
+) recent
+JOIN follows ON follows.followee_id = sqlc.arg(author_id)
+ON CONFLICT DO NOTHING;
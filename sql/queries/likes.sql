@@ -0,0 +1,21 @@
+-- name: AddChirpLike :execrows
+INSERT INTO chirp_likes(chirp_id, user_id, created_at)
+VALUES (
+    $1,
+    $2,
+    $3
+)
+ON CONFLICT DO NOTHING;
+
+-- name: RemoveChirpLike :execrows
+DELETE FROM chirp_likes WHERE chirp_id = $1 AND user_id = $2;
+
+-- name: AdjustChirpLikeCount :one
+UPDATE chirps
+SET like_count = like_count + sqlc.arg(delta)::INT
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: GetLikedChirps :many
+SELECT chirp_id FROM chirp_likes
+WHERE user_id = sqlc.arg(user_id) AND chirp_id = ANY(sqlc.arg(chirp_ids)::UUID[]);
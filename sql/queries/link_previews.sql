@@ -0,0 +1,25 @@
+-- name: CreateLinkPreview :exec
+INSERT INTO link_previews(chirp_id, url, title, description, image_url, site_name, fetched_at)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7
+)
+ON CONFLICT (chirp_id) DO NOTHING;
+
+-- name: GetRecentLinkPreview :one
+SELECT * FROM link_previews
+WHERE url = sqlc.arg(url) AND fetched_at > sqlc.arg(fetched_after)
+ORDER BY fetched_at DESC
+LIMIT 1;
+
+-- name: ListLinkPreviewsForChirps :many
+SELECT * FROM link_previews
+WHERE chirp_id = ANY(sqlc.arg(chirp_ids)::UUID[]);
+
+-- name: DeleteLinkPreview :exec
+DELETE FROM link_previews WHERE chirp_id = $1;
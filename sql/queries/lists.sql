@@ -0,0 +1,79 @@
+-- name: CreateList :one
+INSERT INTO user_lists(id, owner_id, name, is_private, created_at, updated_at)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6
+)
+RETURNING *;
+
+-- name: GetVisibleList :one
+SELECT user_lists.* FROM user_lists
+JOIN users ON users.id = user_lists.owner_id
+WHERE user_lists.id = sqlc.arg(id)
+AND users.deleted_at IS NULL
+AND (NOT user_lists.is_private OR user_lists.owner_id = sqlc.narg(viewer_id)::UUID)
+AND NOT is_blocked_between(sqlc.narg(viewer_id)::UUID, user_lists.owner_id)
+LIMIT 1;
+
+-- name: ListUserLists :many
+SELECT user_lists.* FROM user_lists
+JOIN users ON users.id = user_lists.owner_id
+WHERE user_lists.owner_id = sqlc.arg(owner_id)
+AND users.deleted_at IS NULL
+AND (NOT user_lists.is_private OR user_lists.owner_id = sqlc.narg(viewer_id)::UUID)
+AND NOT is_blocked_between(sqlc.narg(viewer_id)::UUID, user_lists.owner_id)
+AND (sqlc.narg(cursor_created_at)::TIMESTAMP IS NULL
+    OR (user_lists.created_at, user_lists.id) < (sqlc.narg(cursor_created_at)::TIMESTAMP, sqlc.narg(cursor_id)::UUID))
+ORDER BY user_lists.created_at DESC, user_lists.id DESC
+LIMIT sqlc.arg(row_limit);
+
+-- name: UpdateList :one
+UPDATE user_lists
+SET name = $1, is_private = $2, updated_at = $3
+WHERE id = $4 AND owner_id = $5
+RETURNING *;
+
+-- name: DeleteList :execrows
+DELETE FROM user_lists WHERE id = $1 AND owner_id = $2;
+
+-- name: AddListMember :execrows
+INSERT INTO list_members(list_id, user_id, created_at)
+VALUES (
+    $1,
+    $2,
+    $3
+)
+ON CONFLICT DO NOTHING;
+
+-- name: RemoveListMember :execrows
+DELETE FROM list_members WHERE list_id = $1 AND user_id = $2;
+
+-- name: ListListMembers :many
+SELECT sqlc.embed(users), list_members.created_at AS added_at FROM list_members
+JOIN users ON users.id = list_members.user_id
+WHERE list_members.list_id = sqlc.arg(list_id)
+AND users.deleted_at IS NULL
+AND (sqlc.narg(cursor_created_at)::TIMESTAMP IS NULL
+    OR (list_members.created_at, list_members.user_id) < (sqlc.narg(cursor_created_at)::TIMESTAMP, sqlc.narg(cursor_id)::UUID))
+ORDER BY list_members.created_at DESC, list_members.user_id DESC
+LIMIT sqlc.arg(row_limit);
+
+-- name: ListListChirps :many
+SELECT * FROM chirps
+WHERE user_id IN (
+    SELECT list_members.user_id FROM list_members
+    JOIN users ON users.id = list_members.user_id
+    WHERE list_members.list_id = sqlc.arg(list_id) AND users.deleted_at IS NULL)
+AND status = 'published' AND deleted_at IS NULL
+AND NOT is_blocked_between(sqlc.narg(viewer_id)::UUID, chirps.user_id)
+AND NOT EXISTS (
+    SELECT 1 FROM mutes
+    WHERE mutes.muter_id = sqlc.narg(viewer_id)::UUID AND mutes.muted_id = chirps.user_id)
+AND (sqlc.narg(cursor_created_at)::TIMESTAMP IS NULL
+    OR (created_at, id) < (sqlc.narg(cursor_created_at)::TIMESTAMP, sqlc.narg(cursor_id)::UUID))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(row_limit);
@@ -0,0 +1,40 @@
+-- name: CreateMediaAttachment :one
+INSERT INTO media_attachments(id, user_id, created_at, content_type, size_bytes, width, height, blob_key, thumb_key)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8,
+    $9
+)
+RETURNING *;
+
+-- name: AttachMedia :execrows
+UPDATE media_attachments
+SET chirp_id = sqlc.arg(chirp_id), position = array_position(sqlc.arg(ids)::UUID[], id)
+WHERE id = ANY(sqlc.arg(ids)::UUID[])
+AND user_id = sqlc.arg(user_id)
+AND chirp_id IS NULL
+AND held_chirp_id IS NULL;
+
+-- name: HoldMedia :execrows
+UPDATE media_attachments
+SET held_chirp_id = sqlc.arg(held_chirp_id), position = array_position(sqlc.arg(ids)::UUID[], id)
+WHERE id = ANY(sqlc.arg(ids)::UUID[])
+AND user_id = sqlc.arg(user_id)
+AND chirp_id IS NULL
+AND held_chirp_id IS NULL;
+
+-- name: ReleaseHeldMedia :exec
+UPDATE media_attachments
+SET chirp_id = sqlc.arg(chirp_id), held_chirp_id = NULL
+WHERE held_chirp_id = sqlc.arg(held_chirp_id);
+
+-- name: ListMediaForChirps :many
+SELECT * FROM media_attachments
+WHERE chirp_id = ANY(sqlc.arg(chirp_ids)::UUID[])
+ORDER BY chirp_id, position;
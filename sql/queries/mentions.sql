@@ -0,0 +1,32 @@
+-- name: AddChirpMention :exec
+INSERT INTO chirp_mentions(chirp_id, user_id, created_at, start_offset, length)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5
+)
+ON CONFLICT DO NOTHING;
+
+-- name: GetMentionsForChirps :many
+SELECT chirp_mentions.chirp_id, chirp_mentions.user_id, chirp_mentions.start_offset, chirp_mentions.length, users.username
+FROM chirp_mentions
+JOIN users ON users.id = chirp_mentions.user_id
+WHERE chirp_mentions.chirp_id = ANY(sqlc.arg(chirp_ids)::UUID[])
+AND users.deleted_at IS NULL
+AND NOT is_blocked_between(sqlc.narg(viewer_id)::UUID, users.id)
+ORDER BY chirp_mentions.chirp_id, chirp_mentions.start_offset ASC;
+
+-- name: ListMentionChirps :many
+SELECT * FROM chirps
+WHERE id IN (SELECT chirp_id FROM chirp_mentions WHERE chirp_mentions.user_id = sqlc.arg(user_id))
+AND deleted_at IS NULL
+AND NOT is_blocked_between(sqlc.arg(user_id)::UUID, chirps.user_id)
+AND (sqlc.narg(cursor_created_at)::TIMESTAMP IS NULL
+    OR (created_at, id) < (sqlc.narg(cursor_created_at)::TIMESTAMP, sqlc.narg(cursor_id)::UUID))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(row_limit);
+
+-- name: DeleteChirpMentions :exec
+DELETE FROM chirp_mentions WHERE chirp_id = $1;
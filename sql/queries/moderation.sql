@@ -0,0 +1,40 @@
+-- name: ListModerationWords :many
+SELECT * FROM moderation_words ORDER BY word ASC;
+
+-- name: UpsertModerationWord :one
+INSERT INTO moderation_words(word, policy, allowed, created_at, updated_at)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $4
+)
+ON CONFLICT (word) DO UPDATE
+SET policy = EXCLUDED.policy, allowed = EXCLUDED.allowed, updated_at = EXCLUDED.updated_at
+RETURNING *;
+
+-- name: DeleteModerationWord :execrows
+DELETE FROM moderation_words WHERE word = $1;
+
+-- name: CreateHeldChirp :one
+INSERT INTO held_chirps(id, created_at, body, user_id, matched_words, in_reply_to, ref_chirp_id)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7
+)
+RETURNING *;
+
+-- name: ListHeldChirps :many
+SELECT * FROM held_chirps ORDER BY created_at ASC;
+
+-- name: GetHeldChirp :one
+SELECT * FROM held_chirps WHERE id = $1 LIMIT 1;
+
+-- name: DeleteHeldChirp :execrows
+DELETE FROM held_chirps WHERE id = $1;
@@ -0,0 +1,9 @@
+-- name: RecordPolkaEvent :execrows
+INSERT INTO polka_events(id, event, user_id, received_at)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4
+)
+ON CONFLICT (id) DO NOTHING;
@@ -0,0 +1,65 @@
+-- name: CreatePoll :one
+INSERT INTO polls(id, chirp_id, held_chirp_id, closes_at, created_at)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5
+)
+RETURNING *;
+
+-- name: CreatePollOption :exec
+INSERT INTO poll_options(id, poll_id, position, body)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4
+);
+
+-- name: ReleaseHeldPoll :exec
+UPDATE polls
+SET chirp_id = sqlc.arg(chirp_id), held_chirp_id = NULL
+WHERE held_chirp_id = sqlc.arg(held_chirp_id);
+
+-- name: StartPoll :exec
+UPDATE polls
+SET closes_at = sqlc.arg(started_at)::TIMESTAMP + (closes_at - created_at), created_at = sqlc.arg(started_at)
+WHERE chirp_id = sqlc.arg(chirp_id);
+
+-- name: GetPollForChirp :one
+SELECT * FROM polls WHERE chirp_id = $1;
+
+-- name: GetPollOption :one
+SELECT * FROM poll_options WHERE id = $1 AND poll_id = $2;
+
+-- name: VoteInPoll :execrows
+INSERT INTO poll_votes(poll_id, user_id, option_id, created_at)
+SELECT polls.id, sqlc.arg(user_id), poll_options.id, sqlc.arg(created_at)
+FROM polls
+JOIN poll_options ON poll_options.poll_id = polls.id
+WHERE polls.id = sqlc.arg(poll_id)
+AND poll_options.id = sqlc.arg(option_id)
+AND polls.closes_at > sqlc.arg(created_at)
+ON CONFLICT DO NOTHING;
+
+-- name: HasVotedInPoll :one
+SELECT EXISTS (
+    SELECT 1 FROM poll_votes WHERE poll_id = sqlc.arg(poll_id) AND user_id = sqlc.arg(user_id)
+);
+
+-- name: AddPollOptionVote :exec
+UPDATE poll_options SET vote_count = vote_count + 1 WHERE id = $1;
+
+-- name: ListPollOptionsForChirps :many
+SELECT polls.chirp_id, polls.closes_at, poll_options.id, poll_options.body, poll_options.vote_count FROM polls
+JOIN poll_options ON poll_options.poll_id = polls.id
+WHERE polls.chirp_id = ANY(sqlc.arg(chirp_ids)::UUID[])
+ORDER BY polls.chirp_id, poll_options.position;
+
+-- name: ListPollVotesForChirps :many
+SELECT polls.chirp_id, poll_votes.option_id FROM poll_votes
+JOIN polls ON polls.id = poll_votes.poll_id
+WHERE poll_votes.user_id = sqlc.arg(user_id)
+AND polls.chirp_id = ANY(sqlc.arg(chirp_ids)::UUID[]);
@@ -0,0 +1,66 @@
+-- name: ClaimExpiredUsers :many
+SELECT id FROM users
+WHERE deleted_at < sqlc.arg(deleted_before)
+ORDER BY deleted_at
+LIMIT sqlc.arg(row_limit)
+FOR UPDATE SKIP LOCKED;
+
+-- name: ClaimExpiredChirps :many
+SELECT id FROM chirps
+WHERE deleted_at < sqlc.arg(deleted_before)
+ORDER BY deleted_at
+LIMIT sqlc.arg(row_limit)
+FOR UPDATE SKIP LOCKED;
+
+-- name: PurgeMedia :many
+DELETE FROM media_attachments
+WHERE chirp_id = ANY(sqlc.arg(chirp_ids)::UUID[]) OR user_id = ANY(sqlc.arg(user_ids)::UUID[])
+RETURNING *;
+
+-- name: ReleaseUserLikes :exec
+UPDATE chirps
+SET like_count = chirps.like_count - liked.likes
+FROM (
+    SELECT chirp_id, COUNT(*)::INT AS likes FROM chirp_likes
+    WHERE user_id = ANY(sqlc.arg(user_ids)::UUID[])
+    GROUP BY chirp_id
+) liked
+WHERE chirps.id = liked.chirp_id;
+
+-- name: ReleaseUserFollows :many
+UPDATE users
+SET follower_count = users.follower_count - followed.follows
+FROM (
+    SELECT followee_id, COUNT(*)::INT AS follows FROM follows
+    WHERE follower_id = ANY(sqlc.arg(user_ids)::UUID[])
+    GROUP BY followee_id
+) followed
+WHERE users.id = followed.followee_id
+RETURNING users.id, users.follower_count, followed.follows;
+
+-- name: ReleaseUserPollVotes :exec
+UPDATE poll_options
+SET vote_count = poll_options.vote_count - voted.votes
+FROM (
+    SELECT option_id, COUNT(*)::INT AS votes FROM poll_votes
+    WHERE user_id = ANY(sqlc.arg(user_ids)::UUID[])
+    GROUP BY option_id
+) voted
+WHERE poll_options.id = voted.option_id;
+
+-- name: HardDeleteChirps :exec
+DELETE FROM chirps WHERE id = ANY(sqlc.arg(ids)::UUID[]);
+
+-- name: HardDeleteUsers :exec
+DELETE FROM users WHERE id = ANY(sqlc.arg(ids)::UUID[]);
+
+-- name: PurgeUnattachedMedia :many
+DELETE FROM media_attachments
+WHERE id IN (
+    SELECT id FROM media_attachments
+    WHERE chirp_id IS NULL AND held_chirp_id IS NULL
+    AND created_at < sqlc.arg(created_before)
+    ORDER BY created_at
+    LIMIT sqlc.arg(row_limit)
+    FOR UPDATE SKIP LOCKED)
+RETURNING *;
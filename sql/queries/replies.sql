@@ -0,0 +1,41 @@
+-- name: GetChirpAncestors :many
+WITH RECURSIVE ancestors(id, in_reply_to, depth) AS (
+    SELECT parent.id, parent.in_reply_to, 1 FROM chirps child
+    JOIN chirps parent ON parent.id = child.in_reply_to
+    WHERE child.id = sqlc.arg(chirp_id)
+    UNION ALL
+    SELECT chirps.id, chirps.in_reply_to, ancestors.depth + 1 FROM chirps
+    JOIN ancestors ON chirps.id = ancestors.in_reply_to
+    WHERE ancestors.depth < sqlc.arg(max_depth)::INT
+)
+SELECT chirps.* FROM chirps
+JOIN ancestors ON ancestors.id = chirps.id
+WHERE chirps.status = 'published'
+AND NOT is_blocked_between(sqlc.narg(viewer_id)::UUID, chirps.user_id)
+ORDER BY ancestors.depth DESC;
+
+-- name: ListChirpDescendants :many
+WITH RECURSIVE descendants(id, depth) AS (
+    SELECT chirps.id, 1 FROM chirps
+    WHERE chirps.in_reply_to = sqlc.arg(chirp_id)
+    AND chirps.status = 'published'
+    AND NOT is_blocked_between(sqlc.narg(viewer_id)::UUID, chirps.user_id)
+    UNION ALL
+    SELECT chirps.id, descendants.depth + 1 FROM chirps
+    JOIN descendants ON chirps.in_reply_to = descendants.id
+    WHERE descendants.depth < sqlc.arg(max_depth)::INT
+    AND chirps.status = 'published'
+    AND NOT is_blocked_between(sqlc.narg(viewer_id)::UUID, chirps.user_id)
+)
+SELECT sqlc.embed(chirps), descendants.depth::INT AS depth FROM chirps
+JOIN descendants ON descendants.id = chirps.id
+WHERE (sqlc.narg(cursor_created_at)::TIMESTAMP IS NULL
+    OR (chirps.created_at, chirps.id) > (sqlc.narg(cursor_created_at)::TIMESTAMP, sqlc.narg(cursor_id)::UUID))
+ORDER BY chirps.created_at ASC, chirps.id ASC
+LIMIT sqlc.arg(row_limit);
+
+-- name: CountRepliesForChirps :many
+SELECT in_reply_to::UUID AS chirp_id, COUNT(*)::INT AS replies FROM chirps
+WHERE in_reply_to = ANY(sqlc.arg(chirp_ids)::UUID[])
+AND status = 'published' AND deleted_at IS NULL
+GROUP BY in_reply_to;
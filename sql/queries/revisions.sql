@@ -0,0 +1,25 @@
+-- name: GetChirpForEdit :one
+SELECT * FROM chirps
+WHERE id = $1 AND status = 'published' AND deleted_at IS NULL
+FOR UPDATE;
+
+-- name: CreateChirpRevision :exec
+INSERT INTO chirp_revisions(id, chirp_id, body, created_at, replaced_at)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5
+);
+
+-- name: EditChirp :one
+UPDATE chirps
+SET body = sqlc.arg(body), updated_at = sqlc.arg(edited_at), edited_at = sqlc.arg(edited_at)
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: ListChirpRevisions :many
+SELECT * FROM chirp_revisions
+WHERE chirp_id = $1
+ORDER BY replaced_at ASC;
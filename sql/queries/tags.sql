@@ -0,0 +1,53 @@
+-- name: UpsertTag :one
+INSERT INTO tags(id, created_at, name)
+VALUES (
+    $1,
+    $2,
+    $3
+)
+ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name
+RETURNING *;
+
+-- name: AddChirpTag :exec
+INSERT INTO chirp_tags(chirp_id, tag_id, created_at)
+VALUES (
+    $1,
+    $2,
+    $3
+)
+ON CONFLICT DO NOTHING;
+
+-- name: GetTagsForChirps :many
+SELECT chirp_tags.chirp_id, tags.name FROM chirp_tags
+JOIN tags ON tags.id = chirp_tags.tag_id
+WHERE chirp_tags.chirp_id = ANY(sqlc.arg(chirp_ids)::UUID[])
+ORDER BY tags.name ASC;
+
+-- name: ListTagChirps :many
+SELECT chirps.* FROM chirps
+JOIN chirp_tags ON chirp_tags.chirp_id = chirps.id
+JOIN tags ON tags.id = chirp_tags.tag_id
+WHERE tags.name = sqlc.arg(name)
+AND chirps.deleted_at IS NULL
+AND NOT is_blocked_between(sqlc.narg(viewer_id)::UUID, chirps.user_id)
+AND NOT EXISTS (
+    SELECT 1 FROM mutes
+    WHERE mutes.muter_id = sqlc.narg(viewer_id)::UUID AND mutes.muted_id = chirps.user_id)
+AND (sqlc.narg(cursor_created_at)::TIMESTAMP IS NULL
+    OR (chirp_tags.created_at, chirp_tags.chirp_id) < (sqlc.narg(cursor_created_at)::TIMESTAMP, sqlc.narg(cursor_id)::UUID))
+ORDER BY chirp_tags.created_at DESC, chirp_tags.chirp_id DESC
+LIMIT sqlc.arg(row_limit);
+
+-- name: TrendingTags :many
+SELECT tags.name, COUNT(*)::INT AS uses, COUNT(DISTINCT chirps.user_id)::INT AS authors
+FROM chirp_tags
+JOIN tags ON tags.id = chirp_tags.tag_id
+JOIN chirps ON chirps.id = chirp_tags.chirp_id
+WHERE chirp_tags.created_at >= sqlc.arg(since)
+AND chirps.deleted_at IS NULL
+GROUP BY tags.name
+ORDER BY authors DESC, uses DESC, tags.name ASC
+LIMIT sqlc.arg(row_limit);
+
+-- name: DeleteChirpTags :exec
+DELETE FROM chirp_tags WHERE chirp_id = $1;
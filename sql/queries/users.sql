@@ -1,11 +1,12 @@
 -- name: CreateUser :one
-INSERT INTO users (id, created_at, updated_at, email, hashed_password)
+INSERT INTO users (id, created_at, updated_at, email, hashed_password, username)
 VALUES (
     $1,
     $2,
     $3,
     $4,
-    $5
+    $5,
+    $6
 )
 RETURNING *;
 
@@ -13,4 +14,60 @@ RETURNING *;
 DELETE FROM users;
 
 -- name: GetUserFromEmail :one
-SELECT * FROM users WHERE email = $1 LIMIT 1;
\ No newline at end of file
+SELECT * FROM users WHERE email = $1 LIMIT 1;
+
+-- name: GetUserFromId :one
+SELECT * FROM users WHERE id = $1 AND deleted_at IS NULL LIMIT 1;
+
+-- name: UserIsLive :one
+SELECT EXISTS (
+    SELECT 1 FROM users WHERE id = sqlc.arg(id) AND deleted_at IS NULL
+);
+
+-- name: GetVisibleUser :one
+SELECT * FROM users
+WHERE id = sqlc.arg(id) AND deleted_at IS NULL
+AND NOT is_blocked_between(sqlc.narg(viewer_id)::UUID, users.id)
+LIMIT 1;
+
+-- name: UpdateUser :one
+UPDATE users
+SET email = $2, hashed_password = $3, username = $4, updated_at = $5
+WHERE id = $1 AND deleted_at IS NULL
+RETURNING *;
+
+-- name: UpgradeUserToChirpyRed :execrows
+UPDATE users
+SET is_chirpy_red = TRUE, updated_at = $2
+WHERE id = $1 AND deleted_at IS NULL;
+
+-- name: GetMentionableUsers :many
+SELECT * FROM users
+WHERE LOWER(username) = ANY(sqlc.arg(usernames)::TEXT[])
+AND deleted_at IS NULL
+AND NOT is_blocked_between(users.id, sqlc.arg(author_id));
+
+-- name: SoftDeleteUser :execrows
+UPDATE users
+SET deleted_at = sqlc.arg(deleted_at)
+WHERE id = sqlc.arg(id) AND deleted_at IS NULL;
+
+-- name: SoftDeleteUserChirps :exec
+UPDATE chirps
+SET deleted_at = sqlc.arg(deleted_at)
+WHERE deleted_at IS NULL
+AND (user_id = sqlc.arg(user_id)
+    OR (chirp_kind = 'rechirp' AND ref_chirp_id IN (SELECT id FROM chirps authored WHERE authored.user_id = sqlc.arg(user_id))));
+
+-- name: RestoreUser :one
+UPDATE users
+SET deleted_at = NULL
+WHERE id = sqlc.arg(id) AND deleted_at >= sqlc.arg(deleted_since)
+RETURNING *;
+
+-- name: RestoreUserChirps :exec
+UPDATE chirps
+SET deleted_at = NULL
+WHERE deleted_at = sqlc.arg(deleted_at)
+AND (user_id = sqlc.arg(user_id)
+    OR (chirp_kind = 'rechirp' AND ref_chirp_id IN (SELECT id FROM chirps authored WHERE authored.user_id = sqlc.arg(user_id))));
@@ -0,0 +1,7 @@
+-- +goose up
+CREATE INDEX chirps_created_at_id_idx ON chirps(created_at, id);
+CREATE INDEX chirps_user_id_created_at_id_idx ON chirps(user_id, created_at, id);
+
+-- +goose down
+DROP INDEX chirps_user_id_created_at_id_idx;
+DROP INDEX chirps_created_at_id_idx;
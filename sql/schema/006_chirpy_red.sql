@@ -0,0 +1,15 @@
+-- +goose up
+ALTER TABLE users
+ADD COLUMN is_chirpy_red BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE polka_events(
+    id TEXT PRIMARY KEY NOT NULL,
+    event TEXT NOT NULL,
+    user_id UUID NOT NULL,
+    received_at TIMESTAMP NOT NULL);
+
+-- +goose down
+DROP TABLE polka_events;
+
+ALTER TABLE users
+DROP COLUMN is_chirpy_red;
@@ -0,0 +1,18 @@
+-- +goose up
+CREATE TABLE moderation_words(
+    word TEXT PRIMARY KEY NOT NULL,
+    policy TEXT NOT NULL CHECK (policy IN ('censor', 'hold', 'reject')),
+    allowed BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL);
+
+CREATE TABLE held_chirps(
+    id UUID PRIMARY KEY NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    body TEXT NOT NULL,
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE NOT NULL,
+    matched_words TEXT[] NOT NULL);
+
+-- +goose down
+DROP TABLE held_chirps;
+DROP TABLE moderation_words;
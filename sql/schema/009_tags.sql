@@ -0,0 +1,18 @@
+-- +goose up
+CREATE TABLE tags(
+    id UUID PRIMARY KEY NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    name TEXT UNIQUE NOT NULL);
+
+CREATE TABLE chirp_tags(
+    chirp_id UUID REFERENCES chirps(id) ON DELETE CASCADE NOT NULL,
+    tag_id UUID REFERENCES tags(id) ON DELETE CASCADE NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (chirp_id, tag_id));
+
+CREATE INDEX chirp_tags_tag_id_created_at_idx ON chirp_tags(tag_id, created_at, chirp_id);
+CREATE INDEX chirp_tags_created_at_idx ON chirp_tags(created_at);
+
+-- +goose down
+DROP TABLE chirp_tags;
+DROP TABLE tags;
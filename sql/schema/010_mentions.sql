@@ -0,0 +1,23 @@
+-- +goose up
+ALTER TABLE users
+ADD COLUMN username TEXT;
+
+CREATE UNIQUE INDEX users_username_lower_idx ON users(LOWER(username));
+
+CREATE TABLE chirp_mentions(
+    chirp_id UUID REFERENCES chirps(id) ON DELETE CASCADE NOT NULL,
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    start_offset INT NOT NULL,
+    length INT NOT NULL,
+    PRIMARY KEY (chirp_id, start_offset));
+
+CREATE INDEX chirp_mentions_user_id_created_at_idx ON chirp_mentions(user_id, created_at, chirp_id);
+
+-- +goose down
+DROP TABLE chirp_mentions;
+
+DROP INDEX users_username_lower_idx;
+
+ALTER TABLE users
+DROP COLUMN username;
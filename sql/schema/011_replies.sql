@@ -0,0 +1,17 @@
+-- +goose up
+ALTER TABLE chirps
+ADD COLUMN in_reply_to UUID REFERENCES chirps(id) ON DELETE SET NULL;
+
+CREATE INDEX chirps_in_reply_to_created_at_idx ON chirps(in_reply_to, created_at, id);
+
+ALTER TABLE held_chirps
+ADD COLUMN in_reply_to UUID REFERENCES chirps(id) ON DELETE SET NULL;
+
+-- +goose down
+ALTER TABLE held_chirps
+DROP COLUMN in_reply_to;
+
+DROP INDEX chirps_in_reply_to_created_at_idx;
+
+ALTER TABLE chirps
+DROP COLUMN in_reply_to;
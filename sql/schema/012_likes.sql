@@ -0,0 +1,17 @@
+-- +goose up
+ALTER TABLE chirps
+ADD COLUMN like_count INT NOT NULL DEFAULT 0;
+
+CREATE TABLE chirp_likes(
+    chirp_id UUID REFERENCES chirps(id) ON DELETE CASCADE NOT NULL,
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (chirp_id, user_id));
+
+CREATE INDEX chirp_likes_user_id_idx ON chirp_likes(user_id, chirp_id);
+
+-- +goose down
+DROP TABLE chirp_likes;
+
+ALTER TABLE chirps
+DROP COLUMN like_count;
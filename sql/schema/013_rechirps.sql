@@ -0,0 +1,23 @@
+-- +goose up
+ALTER TABLE chirps
+ADD COLUMN chirp_kind TEXT NOT NULL DEFAULT 'original' CHECK (chirp_kind IN ('original', 'rechirp', 'quote')),
+ADD COLUMN ref_chirp_id UUID REFERENCES chirps(id) ON DELETE SET NULL;
+
+CREATE INDEX chirps_ref_chirp_id_idx ON chirps(ref_chirp_id);
+
+CREATE UNIQUE INDEX chirps_rechirp_once_idx ON chirps(user_id, ref_chirp_id) WHERE chirp_kind = 'rechirp';
+
+ALTER TABLE held_chirps
+ADD COLUMN ref_chirp_id UUID REFERENCES chirps(id) ON DELETE SET NULL;
+
+-- +goose down
+ALTER TABLE held_chirps
+DROP COLUMN ref_chirp_id;
+
+DROP INDEX chirps_rechirp_once_idx;
+
+DROP INDEX chirps_ref_chirp_id_idx;
+
+ALTER TABLE chirps
+DROP COLUMN ref_chirp_id,
+DROP COLUMN chirp_kind;
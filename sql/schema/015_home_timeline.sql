@@ -0,0 +1,26 @@
+-- +goose up
+ALTER TABLE users
+ADD COLUMN follower_count INT NOT NULL DEFAULT 0;
+
+UPDATE users SET follower_count = (
+    SELECT COUNT(*) FROM follows WHERE follows.followee_id = users.id);
+
+CREATE TABLE home_timeline(
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE NOT NULL,
+    chirp_id UUID REFERENCES chirps(id) ON DELETE CASCADE NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, chirp_id));
+
+CREATE INDEX home_timeline_user_id_created_at_idx ON home_timeline(user_id, created_at, chirp_id);
+
+INSERT INTO home_timeline(user_id, chirp_id, created_at)
+SELECT chirps.user_id, chirps.id, chirps.created_at FROM chirps
+UNION
+SELECT follows.follower_id, chirps.id, chirps.created_at FROM follows
+JOIN chirps ON chirps.user_id = follows.followee_id;
+
+-- +goose down
+DROP TABLE home_timeline;
+
+ALTER TABLE users
+DROP COLUMN follower_count;
@@ -0,0 +1,21 @@
+-- +goose up
+CREATE TABLE media_attachments(
+    id UUID PRIMARY KEY,
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE NOT NULL,
+    chirp_id UUID REFERENCES chirps(id) ON DELETE CASCADE,
+    held_chirp_id UUID REFERENCES held_chirps(id) ON DELETE SET NULL,
+    position INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL,
+    content_type TEXT NOT NULL,
+    size_bytes INT NOT NULL,
+    width INT NOT NULL,
+    height INT NOT NULL,
+    blob_key TEXT NOT NULL,
+    thumb_key TEXT NOT NULL);
+
+CREATE INDEX media_attachments_chirp_id_idx ON media_attachments(chirp_id, position);
+
+CREATE INDEX media_attachments_held_chirp_id_idx ON media_attachments(held_chirp_id);
+
+-- +goose down
+DROP TABLE media_attachments;
@@ -0,0 +1,14 @@
+-- +goose up
+CREATE TABLE link_previews(
+    chirp_id UUID PRIMARY KEY REFERENCES chirps(id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    title TEXT NOT NULL,
+    description TEXT NOT NULL,
+    image_url TEXT NOT NULL,
+    site_name TEXT NOT NULL,
+    fetched_at TIMESTAMP NOT NULL);
+
+CREATE INDEX link_previews_url_idx ON link_previews(url, fetched_at);
+
+-- +goose down
+DROP TABLE link_previews;
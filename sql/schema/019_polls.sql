@@ -0,0 +1,30 @@
+-- +goose up
+CREATE TABLE polls(
+    id UUID PRIMARY KEY,
+    chirp_id UUID UNIQUE REFERENCES chirps(id) ON DELETE CASCADE,
+    held_chirp_id UUID UNIQUE REFERENCES held_chirps(id) ON DELETE CASCADE,
+    closes_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    CHECK (chirp_id IS NOT NULL OR held_chirp_id IS NOT NULL));
+
+CREATE TABLE poll_options(
+    id UUID PRIMARY KEY,
+    poll_id UUID REFERENCES polls(id) ON DELETE CASCADE NOT NULL,
+    position INT NOT NULL,
+    body TEXT NOT NULL,
+    vote_count INT NOT NULL DEFAULT 0,
+    UNIQUE (poll_id, position));
+
+CREATE TABLE poll_votes(
+    poll_id UUID REFERENCES polls(id) ON DELETE CASCADE NOT NULL,
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE NOT NULL,
+    option_id UUID REFERENCES poll_options(id) ON DELETE CASCADE NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (poll_id, user_id));
+
+-- +goose down
+DROP TABLE poll_votes;
+
+DROP TABLE poll_options;
+
+DROP TABLE polls;
@@ -0,0 +1,18 @@
+-- +goose up
+ALTER TABLE chirps
+ADD COLUMN status TEXT NOT NULL DEFAULT 'published' CHECK (status IN ('draft', 'scheduled', 'published')),
+ADD COLUMN publish_at TIMESTAMP,
+ADD CHECK (status <> 'scheduled' OR publish_at IS NOT NULL);
+
+CREATE INDEX chirps_scheduled_publish_at_idx ON chirps(publish_at) WHERE status = 'scheduled';
+
+CREATE INDEX chirps_unpublished_user_id_idx ON chirps(user_id, created_at, id) WHERE status <> 'published';
+
+-- +goose down
+DROP INDEX chirps_unpublished_user_id_idx;
+
+DROP INDEX chirps_scheduled_publish_at_idx;
+
+ALTER TABLE chirps
+DROP COLUMN publish_at,
+DROP COLUMN status;
@@ -0,0 +1,30 @@
+-- +goose up
+CREATE TABLE bookmarks(
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE NOT NULL,
+    chirp_id UUID REFERENCES chirps(id) ON DELETE CASCADE NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, chirp_id));
+
+CREATE TABLE user_lists(
+    id UUID PRIMARY KEY,
+    owner_id UUID REFERENCES users(id) ON DELETE CASCADE NOT NULL,
+    name TEXT NOT NULL,
+    is_private BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    UNIQUE (owner_id, name));
+
+CREATE TABLE list_members(
+    list_id UUID REFERENCES user_lists(id) ON DELETE CASCADE NOT NULL,
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (list_id, user_id));
+
+CREATE INDEX list_members_user_id_idx ON list_members(user_id);
+
+-- +goose down
+DROP TABLE list_members;
+
+DROP TABLE user_lists;
+
+DROP TABLE bookmarks;
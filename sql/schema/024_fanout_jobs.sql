@@ -0,0 +1,15 @@
+-- +goose up
+CREATE TABLE fanout_jobs(
+    id BIGSERIAL PRIMARY KEY,
+    kind TEXT NOT NULL CHECK (kind IN ('chirp', 'follow', 'unfollow', 'author')),
+    chirp_id UUID,
+    follower_id UUID,
+    followee_id UUID,
+    attempts INT NOT NULL DEFAULT 0,
+    run_after TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL);
+
+CREATE INDEX fanout_jobs_run_after_idx ON fanout_jobs(run_after, id);
+
+-- +goose down
+DROP TABLE fanout_jobs;
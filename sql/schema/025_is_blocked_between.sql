@@ -0,0 +1,13 @@
+-- +goose up
+-- +goose StatementBegin
+CREATE FUNCTION is_blocked_between(a UUID, b UUID) RETURNS BOOLEAN
+LANGUAGE SQL STABLE AS $$
+    SELECT EXISTS (
+        SELECT 1 FROM blocks
+        WHERE (blocker_id = a AND blocked_id = b)
+        OR (blocker_id = b AND blocked_id = a))
+$$;
+-- +goose StatementEnd
+
+-- +goose down
+DROP FUNCTION is_blocked_between(UUID, UUID);
@@ -0,0 +1,6 @@
+-- +goose up
+CREATE INDEX media_attachments_unattached_idx ON media_attachments(created_at)
+WHERE chirp_id IS NULL AND held_chirp_id IS NULL;
+
+-- +goose down
+DROP INDEX media_attachments_unattached_idx;
@@ -0,0 +1,5 @@
+-- +goose up
+CREATE INDEX bookmarks_user_id_created_at_idx ON bookmarks(user_id, created_at, chirp_id);
+
+-- +goose down
+DROP INDEX bookmarks_user_id_created_at_idx;
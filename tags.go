@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/shahanmmiah/Chirpy/internal/chirptext"
+	"github.com/shahanmmiah/Chirpy/internal/database"
+	"github.com/shahanmmiah/Chirpy/internal/pagination"
+)
+
+const TRENDINGWINDOW = 24 * time.Hour
+const MAXTRENDINGWINDOW = 30 * 24 * time.Hour
+
+type TrendingTagJson struct {
+	Name    string `json:"name"`
+	Uses    int32  `json:"uses"`
+	Authors int32  `json:"authors"`
+}
+
+func (a *ApiConfig) MiddlewareGetTagChirps() http.Handler {
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		tag := strings.ToLower(strings.TrimPrefix(req.PathValue("tag"), "#"))
+		names := chirptext.TagNames("#" + tag)
+		if len(names) != 1 || names[0] != tag {
+			ErrorJsonResp(resp, fmt.Errorf("invalid tag %q", req.PathValue("tag")), FAILEDCODE)
+			return
+		}
+
+		page, err := pagination.FromQuery(req.URL.Query())
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		chirpsDb, err := a.DbQueries.ListTagChirps(req.Context(), database.ListTagChirpsParams{
+			Name:            tag,
+			ViewerID:        a.Viewer(req),
+			CursorCreatedAt: page.CursorCreatedAt(),
+			CursorID:        page.CursorID(),
+			RowLimit:        page.FetchLimit()})
+
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		a.WriteChirpPage(resp, req, page, chirpsDb)
+	})
+}
+
+func (a *ApiConfig) MiddlewareGetTrendingTags() http.Handler {
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		window := TRENDINGWINDOW
+		if rawWindow := req.URL.Query().Get("window"); rawWindow != "" {
+			var err error
+			window, err = time.ParseDuration(rawWindow)
+			if err != nil || window <= 0 || window > MAXTRENDINGWINDOW {
+				ErrorJsonResp(resp, fmt.Errorf("window must be a duration up to %v", MAXTRENDINGWINDOW), FAILEDCODE)
+				return
+			}
+		}
+
+		limit, err := pagination.LimitFromQuery(req.URL.Query())
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		trendingDb, err := a.DbQueries.TrendingTags(req.Context(), database.TrendingTagsParams{
+			Since:    time.Now().Add(-window),
+			RowLimit: limit})
+
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		trendingJson := []TrendingTagJson{}
+		for _, t := range trendingDb {
+			trendingJson = append(trendingJson, TrendingTagJson(t))
+		}
+
+		jsonData, err := json.Marshal(trendingJson)
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		resp.Header().Set("Content-type", "application/json")
+		resp.WriteHeader(OKCODE)
+		resp.Write(jsonData)
+	})
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/shahanmmiah/Chirpy/internal/database"
+	"github.com/shahanmmiah/Chirpy/internal/pagination"
+)
+
+// THREADMAXDEPTH bounds how far the thread queries walk up or down a reply
+// chain from the requested chirp.
+const THREADMAXDEPTH = 100
+
+// ThreadReplyJson is a chirp below the requested one. Depth is 1 for direct
+// replies; in_reply_to gives the parent to hang it from.
+type ThreadReplyJson struct {
+	ChirpJson
+	Depth int32 `json:"depth"`
+}
+
+// ThreadJson is a conversation view around one chirp: the chain of parents
+// from the root down, the chirp itself, and a page of everything below it in
+// the order it was posted, so a parent always comes before its replies.
+type ThreadJson struct {
+	Ancestors []ChirpJson       `json:"ancestors"`
+	Chirp     ChirpJson         `json:"chirp"`
+	Replies   []ThreadReplyJson `json:"replies"`
+}
+
+func NullUUIDToPtr(id uuid.NullUUID) *uuid.UUID {
+	if !id.Valid {
+		return nil
+	}
+	return &id.UUID
+}
+
+func (a *ApiConfig) MiddlewareGetThread() http.Handler {
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		id, err := uuid.Parse(req.PathValue("chirpID"))
+		if err != nil {
+			ErrorJsonResp(resp, fmt.Errorf("invalid chirp id: %v", err), FAILEDCODE)
+			return
+		}
+
+		page, err := pagination.FromQuery(req.URL.Query())
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		viewer := a.Viewer(req)
+
+		chirpDb, err := a.DbQueries.GetVisibleChirp(req.Context(), database.GetVisibleChirpParams{
+			ID:       id,
+			ViewerID: viewer})
+
+		if errors.Is(err, sql.ErrNoRows) {
+			ErrorJsonResp(resp, fmt.Errorf("chirp %v not found", id), NOTFOUNDCODE)
+			return
+		}
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		// ancestors are only sent with the first page
+		ancestorsDb := []database.Chirp{}
+		if page.Cursor == nil {
+			ancestorsDb, err = a.DbQueries.GetChirpAncestors(req.Context(), database.GetChirpAncestorsParams{
+				ChirpID:  id,
+				ViewerID: viewer,
+				MaxDepth: THREADMAXDEPTH})
+
+			if err != nil {
+				ErrorJsonResp(resp, err, FAILEDCODE)
+				return
+			}
+		}
+
+		repliesDb, err := a.DbQueries.ListChirpDescendants(req.Context(), database.ListChirpDescendantsParams{
+			ChirpID:         uuid.NullUUID{UUID: id, Valid: true},
+			ViewerID:        viewer,
+			MaxDepth:        THREADMAXDEPTH,
+			CursorCreatedAt: page.CursorCreatedAt(),
+			CursorID:        page.CursorID(),
+			RowLimit:        page.FetchLimit()})
+
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		if len(repliesDb) > int(page.Limit) {
+			repliesDb = repliesDb[:page.Limit]
+			last := repliesDb[len(repliesDb)-1].Chirp
+			resp.Header().Set("Link", pagination.NextLink(req.URL, pagination.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}.Encode()))
+		}
+
+		// enrich the whole thread in one pass
+		threadDb := append(ancestorsDb, chirpDb)
+		for _, r := range repliesDb {
+			threadDb = append(threadDb, r.Chirp)
+		}
+
+		threadJson, err := a.ChirpsToJson(req.Context(), a.Viewer(req), threadDb)
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		thread := ThreadJson{
+			Ancestors: threadJson[:len(ancestorsDb)],
+			Chirp:     threadJson[len(ancestorsDb)],
+			Replies:   []ThreadReplyJson{}}
+
+		for i, r := range repliesDb {
+			thread.Replies = append(thread.Replies, ThreadReplyJson{
+				ChirpJson: threadJson[len(ancestorsDb)+1+i],
+				Depth:     r.Depth})
+		}
+
+		jsonData, err := json.Marshal(thread)
+		if err != nil {
+			ErrorJsonResp(resp, err, FAILEDCODE)
+			return
+		}
+
+		resp.Header().Set("Content-Type", "application/json")
+		resp.WriteHeader(OKCODE)
+		resp.Write(jsonData)
+	})
+}